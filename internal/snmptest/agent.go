@@ -0,0 +1,274 @@
+// Package snmptest provides a minimal in-process SNMP agent for testing SNMP
+// clients without an external SNMP emulator.
+package snmptest
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+)
+
+// Agent is a minimal SNMPv1/v2c agent which serves a fixed set of variables over
+// UDP on the loopback interface. It supports GET, GETNEXT and GETBULK requests.
+//
+// The agent's behavior can be modified to simulate misbehaving agents with
+// Intercept.
+type Agent struct {
+	conn    net.PacketConn
+	version gosnmp.SnmpVersion
+
+	mu        sync.Mutex
+	vars      []gosnmp.SnmpPDU
+	requests  map[gosnmp.PDUType]int
+	intercept InterceptFunc
+
+	done chan struct{}
+}
+
+// InterceptFunc is called for every request an agent receives. If it returns a
+// non-nil packet, that packet is sent as the response; if it returns drop, the
+// request is not responded to. Otherwise, the agent responds as usual.
+type InterceptFunc func(request *gosnmp.SnmpPacket) (response *gosnmp.SnmpPacket, drop bool)
+
+// NewAgent starts a new agent for the given SNMP version which serves the given
+// variables. Variable names may be specified with or without a leading dot.
+func NewAgent(version gosnmp.SnmpVersion, vars ...gosnmp.SnmpPDU) (*Agent, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Agent{
+		conn:     conn,
+		version:  version,
+		requests: map[gosnmp.PDUType]int{},
+		done:     make(chan struct{}),
+	}
+	a.Set(vars...)
+
+	go a.serve()
+	return a, nil
+}
+
+// NewTestAgent starts a new agent like NewAgent, failing the test if the agent can
+// not be started. The caller is responsible for closing the agent.
+func NewTestAgent(t testing.TB, version gosnmp.SnmpVersion, vars ...gosnmp.SnmpPDU) *Agent {
+	t.Helper()
+	agent, err := NewAgent(version, vars...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return agent
+}
+
+// SysObjectID gets the sysObjectID variable (SNMPv2-MIB) with the given value, for
+// agents which identify as a specific vendor's agent.
+func SysObjectID(oid string) gosnmp.SnmpPDU {
+	return gosnmp.SnmpPDU{Name: "1.3.6.1.2.1.1.2.0", Type: gosnmp.ObjectIdentifier, Value: oid}
+}
+
+// Addr gets the address of the agent, in the form "127.0.0.1:port".
+func (a *Agent) Addr() string {
+	return a.conn.LocalAddr().String()
+}
+
+// Close stops the agent.
+func (a *Agent) Close() {
+	_ = a.conn.Close()
+	<-a.done
+}
+
+// Set adds the given variables to the agent, replacing any variables with the
+// same names.
+func (a *Agent) Set(vars ...gosnmp.SnmpPDU) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, v := range vars {
+		v.Name = "." + strings.TrimPrefix(v.Name, ".")
+		a.remove(v.Name)
+		a.vars = append(a.vars, v)
+	}
+	sort.Slice(a.vars, func(i, j int) bool {
+		return compareOids(a.vars[i].Name, a.vars[j].Name) < 0
+	})
+}
+
+// Remove removes the variables with the given names from the agent.
+func (a *Agent) Remove(names ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, name := range names {
+		a.remove("." + strings.TrimPrefix(name, "."))
+	}
+}
+
+// Intercept sets the function which is called for every request the agent
+// receives, replacing any previously set function. Setting nil restores the
+// agent's usual behavior.
+func (a *Agent) Intercept(fn InterceptFunc) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.intercept = fn
+}
+
+// Requests gets the number of requests of the given type the agent has received.
+func (a *Agent) Requests(pduType gosnmp.PDUType) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.requests[pduType]
+}
+
+// remove removes the variable with the given name. The caller must hold the lock.
+func (a *Agent) remove(name string) {
+	for i, v := range a.vars {
+		if v.Name == name {
+			a.vars = append(a.vars[:i], a.vars[i+1:]...)
+			return
+		}
+	}
+}
+
+// serve handles requests until the agent is closed.
+func (a *Agent) serve() {
+	defer close(a.done)
+
+	decoder := &gosnmp.GoSNMP{Version: a.version}
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := a.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		request, err := decoder.SnmpDecodePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		a.mu.Lock()
+		a.requests[request.PDUType]++
+		intercept := a.intercept
+		a.mu.Unlock()
+
+		var response *gosnmp.SnmpPacket
+		if intercept != nil {
+			var drop bool
+			response, drop = intercept(request)
+			if drop {
+				continue
+			}
+		}
+		if response == nil {
+			response = a.respond(request)
+		}
+		response.Version = request.Version
+		response.Community = request.Community
+		response.PDUType = gosnmp.GetResponse
+		response.RequestID = request.RequestID
+
+		out, err := response.MarshalMsg()
+		if err != nil {
+			continue
+		}
+		_, _ = a.conn.WriteTo(out, addr)
+	}
+}
+
+// respond builds the response to a request from the agent's variables.
+func (a *Agent) respond(request *gosnmp.SnmpPacket) *gosnmp.SnmpPacket {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	response := &gosnmp.SnmpPacket{}
+	for i, v := range request.Variables {
+		var result gosnmp.SnmpPDU
+		switch request.PDUType {
+		case gosnmp.GetRequest:
+			result = a.get(v.Name)
+		case gosnmp.GetNextRequest:
+			result = a.next(v.Name)
+		case gosnmp.GetBulkRequest:
+			if i < int(request.NonRepeaters) {
+				result = a.next(v.Name)
+				break
+			}
+			name := v.Name
+			for r := 0; r < int(request.MaxRepetitions); r++ {
+				result = a.next(name)
+				response.Variables = append(response.Variables, result)
+				if result.Type == gosnmp.EndOfMibView {
+					break
+				}
+				name = result.Name
+			}
+			continue
+		default:
+			response.Error = gosnmp.GenErr
+			return response
+		}
+
+		// SNMPv1 has no exception values, so the entire request fails instead.
+		if a.version == gosnmp.Version1 && isException(result.Type) {
+			return &gosnmp.SnmpPacket{
+				Error:      gosnmp.NoSuchName,
+				ErrorIndex: uint8(i + 1),
+				Variables:  request.Variables,
+			}
+		}
+		response.Variables = append(response.Variables, result)
+	}
+	return response
+}
+
+// get gets the variable with the given name. The caller must hold the lock.
+func (a *Agent) get(name string) gosnmp.SnmpPDU {
+	name = "." + strings.TrimPrefix(name, ".")
+	for _, v := range a.vars {
+		if v.Name == name {
+			return v
+		}
+	}
+	return gosnmp.SnmpPDU{Name: name, Type: gosnmp.NoSuchObject}
+}
+
+// next gets the first variable after the given name. The caller must hold the lock.
+func (a *Agent) next(name string) gosnmp.SnmpPDU {
+	name = "." + strings.TrimPrefix(name, ".")
+	for _, v := range a.vars {
+		if compareOids(v.Name, name) > 0 {
+			return v
+		}
+	}
+	return gosnmp.SnmpPDU{Name: name, Type: gosnmp.EndOfMibView}
+}
+
+// isException checks whether the given type is an SNMPv2 exception value.
+func isException(t gosnmp.Asn1BER) bool {
+	return t == gosnmp.NoSuchObject || t == gosnmp.NoSuchInstance || t == gosnmp.EndOfMibView
+}
+
+// compareOids compares two OIDs in lexicographic order of their numeric components.
+func compareOids(a, b string) int {
+	as := strings.Split(strings.Trim(a, "."), ".")
+	bs := strings.Split(strings.Trim(b, "."), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, _ := strconv.Atoi(as[i])
+		bn, _ := strconv.Atoi(bs[i])
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(as) - len(bs)
+}
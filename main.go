@@ -1,12 +1,49 @@
 package main
 
-import "fmt"
+import (
+	"os"
 
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+	exp "github.com/vapor-ware/synse-snmp-base/pkg/plugin"
+)
+
+// mibConfigEnv is the environment variable which sets the directory the generic
+// plugin loads declarative MIB definitions from.
+const mibConfigEnv = "PLUGIN_MIB_CONFIG"
+
+// defaultMibConfig is the directory declarative MIB definitions are loaded from if
+// mibConfigEnv is not set. It is skipped if it does not exist.
+const defaultMibConfig = "/etc/synse/plugin/config/mibs"
+
+// main runs a generic SNMP plugin. Its devices are defined in the Synse device
+// configuration, or are registered for the targets in the dynamic registration
+// configuration from declaratively defined MIBs. See the README for details.
 func main() {
-	fmt.Printf(`
-The Synse SNMP plugin base is used as a common core for SNMP-base
-plugins. For more details on its implementation and usage, see:
+	dir, set := os.LookupEnv(mibConfigEnv)
+	if !set {
+		dir = defaultMibConfig
+	}
+	if _, err := os.Stat(dir); err == nil || set {
+		if err := mibs.DefaultRegistry.LoadDir(dir); err != nil {
+			log.WithError(err).WithField("dir", dir).Fatal("[snmp] failed to load MIB definitions")
+		}
+	}
+
+	plugin, err := exp.NewSnmpBasePlugin(
+		&exp.PluginMetadata{
+			Name:        "snmp",
+			Maintainer:  "vaporio",
+			Description: "A generic SNMP plugin for devices defined in configuration",
+			VCS:         "https://github.com/vapor-ware/synse-snmp-base",
+		},
+		exp.WithOptionalDynamicConfig(),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-https://www.github.com/vapor-ware/synse-snmp-base
-`)
+	if err := plugin.Run(); err != nil {
+		log.Fatal(err)
+	}
 }
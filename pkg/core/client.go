@@ -1,8 +1,10 @@
 package core
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"strconv"
 	"strings"
@@ -17,6 +19,34 @@ var (
 	ErrNonV3SecurityParams = errors.New("cannot define security parameters for SNMP versions other than v3")
 )
 
+// OIDs from SNMPv2-MIB which are used to identify an SNMP agent.
+const (
+	SysObjectIDOid = "1.3.6.1.2.1.1.2.0"
+	SysUpTimeOid   = "1.3.6.1.2.1.1.3.0"
+	SysNameOid     = "1.3.6.1.2.1.1.5.0"
+	SysORIDOid     = "1.3.6.1.2.1.1.9.1.2"
+)
+
+// SnmpEngineIDOid is the OID of the snmpEngineID (SNMP-FRAMEWORK-MIB), which uniquely
+// identifies an SNMP engine.
+const SnmpEngineIDOid = "1.3.6.1.6.3.10.2.1.1.0"
+
+// EntPhysicalSerialNumOid is the OID of the serial number of the first physical entity
+// (entPhysicalSerialNum.1 in ENTITY-MIB), which is the device itself for most agents.
+const EntPhysicalSerialNumOid = "1.3.6.1.2.1.47.1.1.1.1.11.1"
+
+// AgentIdentity holds the information an SNMP agent exposes about itself, which
+// is used to determine which MIBs the agent implements.
+type AgentIdentity struct {
+	// SysObjectID is the vendor's authoritative identification of the
+	// network management subsystem (sysObjectID).
+	SysObjectID string
+
+	// Capabilities are the OIDs of the capabilities the agent advertises
+	// in its sysORTable (sysORID).
+	Capabilities []string
+}
+
 // Client is a wrapper around a GoSNMP struct which adds some utility
 // functions around it. Notably, it enables lazy connecting to the client,
 // so the SNMP agent does not need to be reachable at plugin startup.
@@ -24,6 +54,87 @@ type Client struct {
 	*gosnmp.GoSNMP
 
 	isConnected bool
+
+	// walkConfig configures how the client walks the agent's subtrees.
+	walkConfig SnmpWalkConfiguration
+
+	// bulkFailed is set once walking the agent with GETBULK has failed, so
+	// further walks use GETNEXT.
+	bulkFailed bool
+
+	// health is the health of the client's agent, which is updated with the
+	// outcome of each request made with the client. It is only set for clients
+	// created for a target (see Target.NewClient).
+	health *AgentHealth
+}
+
+// Connect connects to the agent. A failure to connect is recorded as a failed
+// request to the agent. Once connected, requests made with the client reuse the
+// connection until the client is closed.
+func (c *Client) Connect() error {
+	err := c.GoSNMP.Connect()
+	if err != nil {
+		c.observe(time.Now(), err)
+		return err
+	}
+	c.isConnected = true
+	return nil
+}
+
+// Get sends a GET request to the agent, recording the outcome and round-trip time
+// of the request in the agent's health. Agent restarts are detected from the
+// response as well.
+func (c *Client) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	start := time.Now()
+	result, err := c.GoSNMP.Get(oids)
+	c.observe(start, err)
+	c.observeResponse(result, err)
+	return result, err
+}
+
+// GetNext sends a GETNEXT request to the agent, recording the outcome and
+// round-trip time of the request in the agent's health. Agent restarts are
+// detected from the response as well.
+func (c *Client) GetNext(oids []string) (*gosnmp.SnmpPacket, error) {
+	start := time.Now()
+	result, err := c.GoSNMP.GetNext(oids)
+	c.observe(start, err)
+	c.observeResponse(result, err)
+	return result, err
+}
+
+// GetBulk sends a GETBULK request to the agent, recording the outcome and
+// round-trip time of the request in the agent's health. Agent restarts are
+// detected from the response as well.
+func (c *Client) GetBulk(oids []string, nonRepeaters uint8, maxRepetitions uint8) (*gosnmp.SnmpPacket, error) {
+	start := time.Now()
+	result, err := c.GoSNMP.GetBulk(oids, nonRepeaters, maxRepetitions)
+	c.observe(start, err)
+	c.observeResponse(result, err)
+	return result, err
+}
+
+// observe records the outcome of a request which was sent at the given time in
+// the agent's health, if it is tracked.
+func (c *Client) observe(start time.Time, err error) {
+	if c.health != nil {
+		c.health.Observe(time.Since(start), err)
+	}
+}
+
+// observeResponse records the agent's sysUpTime and snmpEngineBoots from a response,
+// if they are present, so agent restarts are detected from any request made to the
+// agent (see AgentHealth.ObserveUptime and AgentHealth.ObserveEngineBoots).
+func (c *Client) observeResponse(response *gosnmp.SnmpPacket, err error) {
+	if c.health != nil && err == nil {
+		c.health.observeResponse(response)
+	}
+}
+
+// Health gets the health of the client's agent. It is nil if the client was not
+// created for a target (see Target.NewClient).
+func (c *Client) Health() *AgentHealth {
+	return c.health
 }
 
 // GetOid gets the value for a specified OID.
@@ -48,44 +159,310 @@ func (c *Client) GetOid(oid string) (*gosnmp.SnmpPDU, error) {
 	return &data, nil
 }
 
+// GetOids gets the values for the specified OIDs in a single request. The values
+// are returned in the order of the given OIDs.
+func (c *Client) GetOids(oids ...string) ([]gosnmp.SnmpPDU, error) {
+	if !c.isConnected {
+		log.Debug("[snmp] client establishing connection with agent")
+		if err := c.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := c.Get(oids)
+	if err != nil {
+		log.WithError(err).Error("[snmp] client failed to get OIDs")
+		return nil, err
+	}
+	if result.Error != gosnmp.NoError {
+		return nil, fmt.Errorf("agent failed to get OIDs: %v", result.Error)
+	}
+	if len(result.Variables) != len(oids) {
+		return nil, fmt.Errorf("agent returned %d values for %d OIDs", len(result.Variables), len(oids))
+	}
+	return result.Variables, nil
+}
+
+// DiscoveredValues maps the OIDs found on an agent during discovery to the values
+// the agent returned for them.
+type DiscoveredValues map[string]gosnmp.SnmpPDU
+
+// OIDs gets the set of discovered OIDs.
+func (values DiscoveredValues) OIDs() map[string]struct{} {
+	oids := make(map[string]struct{}, len(values))
+	for oid := range values {
+		oids[oid] = struct{}{}
+	}
+	return oids
+}
+
 // GetSupportedDevices gets all the OIDs for devices found on the target. This may not
 // always be the full set of devices that a MIB defines.
 //
+// Each of the given root OIDs is walked and the results are merged, so MIBs which
+// span multiple subtrees can be discovered in a single call.
+//
 // This returns a map of OIDs to empty struct. This map should be used during device creation
 // to filter the MIB to only register those devices that a target supports. It is returned
 // as a map to make OID lookups easier than iterating over a slice. Presence in the map means
 // the device is supported, absence means it is not.
-func (c *Client) GetSupportedDevices(rootOid string) (map[string]struct{}, error) {
-	log.WithFields(log.Fields{
-		"rootOid": rootOid,
-	}).Debug("[snmp] getting supported devices for root OIO")
+func (c *Client) GetSupportedDevices(rootOids ...string) (map[string]struct{}, error) {
+	values, err := c.GetSupportedValues(rootOids...)
+	if _, partial := err.(*PartialDiscoveryError); err != nil && !partial {
+		return nil, err
+	}
+	return values.OIDs(), nil
+}
+
+// GetSupportedValues walks each of the given root OIDs, like GetSupportedDevices,
+// but keeps the values the agent returned along with the OIDs.
+//
+// If any of the walks ends early, the values which were discovered are returned
+// along with a PartialDiscoveryError, so that callers can tell which OIDs were not
+// reached by the walks.
+func (c *Client) GetSupportedValues(rootOids ...string) (DiscoveredValues, error) {
+	values := make(DiscoveredValues)
+	var partial []*PartialWalkError
+	for _, rootOid := range rootOids {
+		log.WithFields(log.Fields{
+			"rootOid": rootOid,
+		}).Debug("[snmp] getting supported devices for root OID")
+
+		results, err := c.WalkSubtree(rootOid)
+		if err != nil {
+			// The results of a walk which ended early are still used, as
+			// a partial set of supported devices is better than none.
+			perr, ok := err.(*PartialWalkError)
+			if !ok {
+				log.WithError(err).Error("[snmp] failed to walk root OID")
+				return nil, err
+			}
+			log.WithError(err).WithFields(log.Fields{
+				"rootOid": rootOid,
+			}).Warn("[snmp] only partially walked root OID; some supported devices may be missing")
+			partial = append(partial, perr)
+		}
+
+		log.WithFields(log.Fields{
+			"size": len(results),
+		}).Debug("[snmp] got walk results")
+
+		for _, r := range results {
+			oid := NormalizeOid(r.Name)
+
+			log.WithFields(log.Fields{
+				"name":  oid,
+				"value": r.Value,
+				"type":  r.Type,
+			}).Debug("[snmp] collecting walk result")
+			values[oid] = r
+		}
+	}
 
-	results, err := c.BulkWalkAll(rootOid)
+	if len(partial) != 0 {
+		return values, &PartialDiscoveryError{Walks: partial}
+	}
+	return values, nil
+}
+
+// ProbeOids checks which of the given OIDs the agent supports by getting them
+// directly, rather than walking the subtrees which contain them. The OIDs are
+// requested in batches of up to MaxOids OIDs per request.
+//
+// The returned map has the same form as the one returned by GetSupportedDevices.
+func (c *Client) ProbeOids(oids ...string) (map[string]struct{}, error) {
+	values, err := c.ProbeValues(oids...)
 	if err != nil {
-		log.WithError(err).Error("[snmp] failed to bulk walk all")
 		return nil, err
 	}
+	return values.OIDs(), nil
+}
+
+// ProbeValues probes the given OIDs, like ProbeOids, but keeps the values the
+// agent returned along with the OIDs.
+func (c *Client) ProbeValues(oids ...string) (DiscoveredValues, error) {
+	batchSize := c.MaxOids
+	if batchSize <= 0 {
+		batchSize = gosnmp.MaxOids
+	}
+
+	values := make(DiscoveredValues)
+	for start := 0; start < len(oids); start += batchSize {
+		end := start + batchSize
+		if end > len(oids) {
+			end = len(oids)
+		}
+		batch := oids[start:end]
+
+		result, err := c.Get(batch)
+		if err != nil {
+			log.WithError(err).Error("[snmp] failed to probe OIDs")
+			return nil, err
+		}
+		if result.Error == gosnmp.NoError {
+			collectValues(values, result.Variables)
+			continue
+		}
+
+		// SNMPv1 agents fail the entire request if any of the OIDs does not
+		// exist, so fall back to getting each OID of the batch individually.
+		log.WithFields(log.Fields{
+			"error": result.Error,
+			"size":  len(batch),
+		}).Debug("[snmp] batched probe failed; probing OIDs individually")
+		for _, oid := range batch {
+			result, err := c.Get([]string{oid})
+			if err != nil {
+				log.WithError(err).Error("[snmp] failed to probe OID")
+				return nil, err
+			}
+			if result.Error == gosnmp.NoError {
+				collectValues(values, result.Variables)
+			}
+		}
+	}
 
 	log.WithFields(log.Fields{
-		"size": len(results),
-	}).Debug("[snmp] got bulk walk results")
+		"probed":    len(oids),
+		"supported": len(values),
+	}).Debug("[snmp] got probe results")
+	return values, nil
+}
 
-	oids := make(map[string]struct{})
+// ProbeColumns gets the OIDs of every instance of the given table columns using
+// GETNEXT, which only visits the columns themselves rather than their entire
+// tables or MIB subtrees.
+//
+// The returned map has the same form as the one returned by GetSupportedDevices.
+func (c *Client) ProbeColumns(columns ...string) (map[string]struct{}, error) {
+	values, err := c.ProbeColumnValues(columns...)
+	if err != nil {
+		return nil, err
+	}
+	return values.OIDs(), nil
+}
+
+// ProbeColumnValues probes the given table columns, like ProbeColumns, but keeps
+// the values the agent returned along with the OIDs.
+func (c *Client) ProbeColumnValues(columns ...string) (DiscoveredValues, error) {
+	values := make(DiscoveredValues)
+	for _, column := range columns {
+		results, err := c.walk(column, false)
+		if err != nil {
+			log.WithError(err).WithField("column", column).Error("[snmp] failed to probe table column")
+			return nil, err
+		}
+		collectValues(values, results)
+	}
+	return values, nil
+}
+
+// collectValues adds the variables which hold a value to the discovered values.
+func collectValues(values DiscoveredValues, variables []gosnmp.SnmpPDU) {
+	for _, v := range variables {
+		switch v.Type {
+		case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+			continue
+		}
+		values[NormalizeOid(v.Name)] = v
+	}
+}
+
+// GetAgentIdentity gets the sysObjectID and the sysORTable capabilities of the agent.
+//
+// Not all agents populate the sysORTable, so failing to walk it is not considered
+// an error; the identity is returned with no capabilities in that case.
+func (c *Client) GetAgentIdentity() (*AgentIdentity, error) {
+	sysObjectID, err := c.GetSysObjectID()
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &AgentIdentity{
+		SysObjectID: sysObjectID,
+	}
+
+	results, err := c.WalkSubtree(SysORIDOid)
+	if err != nil {
+		if _, partial := err.(*PartialWalkError); !partial {
+			log.WithError(err).Warn("[snmp] failed to walk agent sysORTable; continuing without capabilities")
+			return identity, nil
+		}
+		log.WithError(err).Warn("[snmp] only partially walked agent sysORTable; some capabilities may be missing")
+	}
 	for _, r := range results {
-		oid := r.Name
-		if strings.HasPrefix(oid, ".") {
-			oid = oid[1:]
+		if capability, ok := r.Value.(string); ok {
+			identity.Capabilities = append(identity.Capabilities, NormalizeOid(capability))
 		}
+	}
 
-		log.WithFields(log.Fields{
-			"name":  oid,
-			"value": r.Value,
-			"type":  r.Type,
-		}).Debug("[snmp] collecting walk result")
-		oids[oid] = struct{}{}
+	log.WithFields(log.Fields{
+		"sysObjectID":  identity.SysObjectID,
+		"capabilities": identity.Capabilities,
+	}).Debug("[snmp] got agent identity")
+	return identity, nil
+}
+
+// GetSysObjectID gets the sysObjectID of the agent, without a leading dot.
+func (c *Client) GetSysObjectID() (string, error) {
+	result, err := c.GetOid(SysObjectIDOid)
+	if err != nil {
+		return "", err
+	}
+	sysObjectID, ok := result.Value.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected sysObjectID value: %v (%T)", result.Value, result.Value)
 	}
+	return NormalizeOid(sysObjectID), nil
+}
 
-	return oids, nil
+// GetEngineID gets the snmpEngineID of the agent, hex encoded. If the agent does not
+// expose its snmpEngineID, the authoritative engine ID learned when connecting to an
+// SNMPv3 agent is used instead.
+func (c *Client) GetEngineID() (string, error) {
+	result, err := c.GetOid(SnmpEngineIDOid)
+	if err != nil {
+		return "", err
+	}
+	if id, ok := result.Value.([]byte); ok && len(id) != 0 {
+		return hex.EncodeToString(id), nil
+	}
+	if sp, ok := c.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && c.Version == gosnmp.Version3 && sp.AuthoritativeEngineID != "" {
+		return hex.EncodeToString([]byte(sp.AuthoritativeEngineID)), nil
+	}
+	return "", fmt.Errorf("agent does not expose snmpEngineID")
+}
+
+// GetString gets the value of an OID whose value is a string (an OCTET STRING), with
+// surrounding whitespace removed. If the agent does not support the OID, or its value
+// is empty, an error is returned.
+func (c *Client) GetString(oid string) (string, error) {
+	result, err := c.GetOid(oid)
+	if err != nil {
+		return "", err
+	}
+	value, ok := result.Value.([]byte)
+	if !ok {
+		return "", fmt.Errorf("unexpected value for OID %s: %v (%T)", oid, result.Value, result.Value)
+	}
+	s := strings.TrimSpace(string(value))
+	if s == "" {
+		return "", fmt.Errorf("empty value for OID %s", oid)
+	}
+	return s, nil
+}
+
+// GetUptime gets the sysUpTime of the agent, in hundredths of a second.
+func (c *Client) GetUptime() (uint32, error) {
+	result, err := c.GetOid(SysUpTimeOid)
+	if err != nil {
+		return 0, err
+	}
+	ticks, ok := result.Value.(uint32)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sysUpTime value: %v (%T)", result.Value, result.Value)
+	}
+	return ticks, nil
 }
 
 // Close the client connection.
@@ -93,6 +470,7 @@ func (c *Client) Close() {
 	if c.Conn != nil {
 		_ = c.Conn.Close()
 	}
+	c.isConnected = false
 }
 
 // NewClient creates a new instance of an SNMP Client for the given SNMP target
@@ -171,37 +549,13 @@ func NewClient(cfg *SnmpTargetConfiguration) (*Client, error) {
 		}
 	}
 
-	agent := cfg.Agent
-	if !strings.Contains(agent, "://") {
-		agent = "udp://" + agent
-	}
-
-	u, err := url.Parse(agent)
-	if err != nil {
-		return nil, err
-	}
-
-	var transport string
-	switch u.Scheme {
-	case "tcp":
-		transport = "tcp"
-	case "", "udp":
-		transport = "udp"
-	default:
-		return nil, fmt.Errorf("unsupported transport scheme: %s", u.Scheme)
-	}
-
-	portStr := u.Port()
-	if portStr == "" {
-		portStr = "161"
-	}
-	port, err := strconv.ParseUint(portStr, 10, 16)
+	transport, host, port, err := parseAgent(cfg.Agent)
 	if err != nil {
 		return nil, err
 	}
 
 	log.WithFields(log.Fields{
-		"host":      u.Hostname(),
+		"host":      host,
 		"port":      port,
 		"transport": transport,
 	}).Debug("[snmp] parsed client agent config")
@@ -221,20 +575,73 @@ func NewClient(cfg *SnmpTargetConfiguration) (*Client, error) {
 	c := &Client{
 		GoSNMP: &gosnmp.GoSNMP{
 			Version:            version,
-			Target:             u.Hostname(),
-			Port:               uint16(port),
+			Target:             host,
+			Port:               port,
 			Transport:          transport,
 			Timeout:            timeout,
 			Retries:            retries,
 			Community:          cfg.Community,
 			MsgFlags:           msgFlags,
 			SecurityModel:      securityModel,
-			SecurityParameters: securityParams,
 			ContextName:        contextName,
 			ExponentialTimeout: true,
 			MaxOids:            gosnmp.MaxOids,
+			MaxRepetitions:     cfg.Walk.MaxRepetitions,
+			NonRepeaters:       cfg.Walk.NonRepeaters,
 		},
+		walkConfig: cfg.Walk,
+	}
+
+	// Only set the security parameters if they are defined. Setting a nil pointer
+	// would result in a non-nil interface value, which gosnmp would then attempt
+	// to use when building requests.
+	if securityParams != nil {
+		c.SecurityParameters = securityParams
 	}
 
 	return c, nil
 }
+
+// parseAgent parses a configured agent address into its transport, host and port. If
+// the address has no transport scheme, "udp" is used; if it has no port, 161 is used.
+func parseAgent(agent string) (transport, host string, port uint16, err error) {
+	if !strings.Contains(agent, "://") {
+		agent = "udp://" + agent
+	}
+
+	u, err := url.Parse(agent)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		transport = "tcp"
+	case "", "udp":
+		transport = "udp"
+	default:
+		return "", "", 0, fmt.Errorf("unsupported transport scheme: %s", u.Scheme)
+	}
+
+	portStr := u.Port()
+	if portStr == "" {
+		portStr = "161"
+	}
+	p, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return transport, u.Hostname(), uint16(p), nil
+}
+
+// CanonicalAgent gets the canonical form of a configured agent address,
+// "<transport>://<host>:<port>", with the default transport and port filled in and
+// the host in lower case. Addresses which refer to the same agent in different ways,
+// e.g. "10.0.0.5" and "udp://10.0.0.5:161", have the same canonical form.
+func CanonicalAgent(agent string) (string, error) {
+	transport, host, port, err := parseAgent(agent)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s://%s", transport, net.JoinHostPort(strings.ToLower(host), strconv.Itoa(int(port)))), nil
+}
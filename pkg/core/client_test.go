@@ -6,6 +6,7 @@ import (
 
 	"github.com/soniah/gosnmp"
 	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
 )
 
 func TestClient_Close(t *testing.T) {
@@ -71,6 +72,10 @@ func TestNewClient2(t *testing.T) {
 	assert.Equal(t, gosnmp.Version2c, client.Version)
 	assert.Equal(t, 1*time.Second, client.Timeout)
 	assert.Equal(t, 1, client.Retries)
+
+	// Without v3 security, the security parameters must be a nil interface rather
+	// than a nil pointer, which gosnmp would dereference when building requests.
+	assert.True(t, client.SecurityParameters == nil)
 }
 
 func TestNewClient3(t *testing.T) {
@@ -320,6 +325,116 @@ func TestNewClient_BadPrivProtocol(t *testing.T) {
 	assert.Equal(t, ErrInvalidPrivProtocol, err)
 }
 
+func TestCanonicalAgent(t *testing.T) {
+	tests := []struct {
+		agent     string
+		canonical string
+	}{
+		{"10.0.0.5", "udp://10.0.0.5:161"},
+		{"10.0.0.5:161", "udp://10.0.0.5:161"},
+		{"udp://10.0.0.5", "udp://10.0.0.5:161"},
+		{"tcp://10.0.0.5:1161", "tcp://10.0.0.5:1161"},
+		{"PDU-1.Example.com", "udp://pdu-1.example.com:161"},
+		{"udp://[fe80::1]:162", "udp://[fe80::1]:162"},
+	}
+
+	for _, test := range tests {
+		canonical, err := CanonicalAgent(test.agent)
+		assert.NoError(t, err, test.agent)
+		assert.Equal(t, test.canonical, canonical, test.agent)
+	}
+}
+
+func TestCanonicalAgent_BadAgent(t *testing.T) {
+	_, err := CanonicalAgent("http://10.0.0.5")
+	assert.EqualError(t, err, "unsupported transport scheme: http")
+}
+
+func TestClient_Connect_Reused(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: SysNameOid, Type: gosnmp.OctetString, Value: []byte("pdu-1")})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{})
+	defer client.Close()
+	conn := client.Conn
+
+	// Requests made after connecting do not dial the agent again.
+	_, err := client.GetOid("1.2.3.1.0")
+	assert.NoError(t, err)
+	_, err = client.GetOids("1.2.3.1.0", "1.2.3.2.1")
+	assert.NoError(t, err)
+	_, err = client.GetString(SysNameOid)
+	assert.NoError(t, err)
+	assert.True(t, client.Conn == conn)
+
+	// Once closed, the client connects again.
+	client.Close()
+	_, err = client.GetOid("1.2.3.1.0")
+	assert.NoError(t, err)
+	assert.True(t, client.Conn != conn)
+}
+
+func TestClient_GetEngineID(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: SnmpEngineIDOid, Type: gosnmp.OctetString, Value: []byte{0x80, 0x00, 0x1f, 0x88, 0x01}})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	id, err := client.GetEngineID()
+	assert.NoError(t, err)
+	assert.Equal(t, "80001f8801", id)
+
+	agent.Remove(SnmpEngineIDOid)
+	_, err = client.GetEngineID()
+	assert.Error(t, err)
+}
+
+func TestClient_GetString(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: SysNameOid, Type: gosnmp.OctetString, Value: []byte(" pdu-1\n")})
+	agent.Set(gosnmp.SnmpPDU{Name: EntPhysicalSerialNumOid, Type: gosnmp.OctetString, Value: []byte("")})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	name, err := client.GetString(SysNameOid)
+	assert.NoError(t, err)
+	assert.Equal(t, "pdu-1", name)
+
+	_, err = client.GetString(EntPhysicalSerialNumOid)
+	assert.EqualError(t, err, "empty value for OID "+EntPhysicalSerialNumOid)
+
+	_, err = client.GetString("1.2.3.1.0")
+	assert.Error(t, err)
+
+	_, err = client.GetString("1.2.3.99.0")
+	assert.Error(t, err)
+}
+
+func TestCollectValues(t *testing.T) {
+	values := DiscoveredValues{}
+	collectValues(values, []gosnmp.SnmpPDU{
+		{Name: ".1.2.3.1.0", Type: gosnmp.Integer, Value: 1},
+		{Name: ".1.2.3.2.0", Type: gosnmp.NoSuchObject},
+		{Name: ".1.2.3.3.0", Type: gosnmp.NoSuchInstance},
+		{Name: ".1.2.3.4.0", Type: gosnmp.EndOfMibView},
+		{Name: ".1.2.3.5.0", Type: gosnmp.OctetString, Value: []byte("foo")},
+	})
+
+	assert.Equal(t, DiscoveredValues{
+		"1.2.3.1.0": {Name: ".1.2.3.1.0", Type: gosnmp.Integer, Value: 1},
+		"1.2.3.5.0": {Name: ".1.2.3.5.0", Type: gosnmp.OctetString, Value: []byte("foo")},
+	}, values)
+	assert.Equal(t, map[string]struct{}{
+		"1.2.3.1.0": {},
+		"1.2.3.5.0": {},
+	}, values.OIDs())
+}
+
 //
 // Integration tests
 //
@@ -552,3 +667,49 @@ func TestClientGetSupportedDevicesInvalidRootOIDIntegration(t *testing.T) {
 	assert.EqualError(t, err, `marshal: unable to parse OID: strconv.Atoi: parsing "foo": invalid syntax`)
 	assert.Nil(t, devices)
 }
+
+func TestClientProbeOidsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test: --short flag set")
+	}
+
+	client, err := NewClient(getEmulatorClientConfig())
+	defer client.Close()
+	assert.NoError(t, err)
+
+	err = client.Connect()
+	assert.NoError(t, err)
+
+	devices, err := client.ProbeOids(
+		"1.3.6.1.2.1.33.1.1.1.0",
+		"1.3.6.1.2.1.33.1.1.2.0",
+		"1.3.6.1.2.1.33.1.1.100.100",
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"1.3.6.1.2.1.33.1.1.1.0": {},
+		"1.3.6.1.2.1.33.1.1.2.0": {},
+	}, devices)
+}
+
+func TestClientProbeColumnsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test: --short flag set")
+	}
+
+	client, err := NewClient(getEmulatorClientConfig())
+	defer client.Close()
+	assert.NoError(t, err)
+
+	err = client.Connect()
+	assert.NoError(t, err)
+
+	// upsInputFrequency column of the upsInputTable.
+	devices, err := client.ProbeColumns("1.3.6.1.2.1.33.1.3.3.1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"1.3.6.1.2.1.33.1.3.3.1.2.1": {},
+		"1.3.6.1.2.1.33.1.3.3.1.2.2": {},
+		"1.3.6.1.2.1.33.1.3.3.1.2.3": {},
+	}, devices)
+}
@@ -0,0 +1,357 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ComputeFunc computes the reading value of a computed device from the values of
+// its input OIDs, given in the order the inputs are defined.
+type ComputeFunc func(values []interface{}) (interface{}, error)
+
+// ErrDivisionByZero is the error returned when evaluating an expression which
+// divides by zero.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// expressionFuncs are the functions which may be used in expressions. Each takes
+// one or more arguments.
+var expressionFuncs = map[string]func(args []float64) float64{
+	"sum": func(args []float64) float64 {
+		var total float64
+		for _, a := range args {
+			total += a
+		}
+		return total
+	},
+	"avg": func(args []float64) float64 {
+		var total float64
+		for _, a := range args {
+			total += a
+		}
+		return total / float64(len(args))
+	},
+	"min": func(args []float64) float64 {
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Min(m, a)
+		}
+		return m
+	},
+	"max": func(args []float64) float64 {
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Max(m, a)
+		}
+		return m
+	},
+}
+
+// ParseExpression parses an arithmetic expression over the values of a computed
+// device's inputs, returning a ComputeFunc which evaluates it.
+//
+// Expressions may use numbers, the input values $1, $2, ... (in the order the
+// inputs are defined), the operators +, -, * and /, parentheses, and the functions
+// sum, avg, min and max, e.g. "$1 * $2" or "sum($1, $2, $3) / 1000". The number of
+// inputs is used to check that the expression only refers to existing inputs.
+//
+// Input values must be numeric (see ToFloat). The computed value is a float64.
+func ParseExpression(expression string, inputs int) (ComputeFunc, error) {
+	p := &parser{input: expression, inputs: inputs}
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+
+	return func(values []interface{}) (interface{}, error) {
+		if len(values) != inputs {
+			return nil, fmt.Errorf("expression expects %d values, got %d", inputs, len(values))
+		}
+		args := make([]float64, len(values))
+		for i, v := range values {
+			f, err := ToFloat(v)
+			if err != nil {
+				return nil, fmt.Errorf("input $%d: %v", i+1, err)
+			}
+			args[i] = f
+		}
+		return root.eval(args)
+	}, nil
+}
+
+// ToFloat converts a numeric value, as returned by an agent or decoded by a decoder
+// (see DecodeValue), to a float64. Strings which hold a number are converted as well.
+func ToFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not a number", v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("value %v (%T) is not a number", value, value)
+}
+
+// node is a node of a parsed expression.
+type node interface {
+	eval(args []float64) (float64, error)
+}
+
+// numberNode is a number literal.
+type numberNode float64
+
+func (n numberNode) eval(_ []float64) (float64, error) {
+	return float64(n), nil
+}
+
+// inputNode is a reference to the value of an input, by its index.
+type inputNode int
+
+func (n inputNode) eval(args []float64) (float64, error) {
+	return args[n], nil
+}
+
+// negateNode is a negated sub-expression.
+type negateNode struct {
+	operand node
+}
+
+func (n *negateNode) eval(args []float64) (float64, error) {
+	v, err := n.operand.eval(args)
+	return -v, err
+}
+
+// binaryNode is an arithmetic operation on two sub-expressions.
+type binaryNode struct {
+	op          byte
+	left, right node
+}
+
+func (n *binaryNode) eval(args []float64) (float64, error) {
+	l, err := n.left.eval(args)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(args)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	default:
+		if r == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return l / r, nil
+	}
+}
+
+// callNode is a call of one of the expression functions.
+type callNode struct {
+	fn   func(args []float64) float64
+	args []node
+}
+
+func (n *callNode) eval(args []float64) (float64, error) {
+	values := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(args)
+		if err != nil {
+			return 0, err
+		}
+		values[i] = v
+	}
+	return n.fn(values), nil
+}
+
+// parser is a recursive descent parser for expressions.
+type parser struct {
+	input  string
+	pos    int
+	inputs int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid expression %q at position %d: %s", p.input, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek gets the next non-space character, or 0 at the end of the input.
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// parseExpression parses a sum or difference of terms.
+func (p *parser) parseExpression() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+// parseTerm parses a product or quotient of factors.
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+// parseFactor parses a number, an input, a function call, a negation or a
+// parenthesized expression.
+func (p *parser) parseFactor() (node, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end of expression")
+
+	case c == '-':
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &negateNode{operand: operand}, nil
+
+	case c == '(':
+		p.pos++
+		n, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return n, nil
+
+	case c == '$':
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.input) && unicode.IsDigit(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		i, err := strconv.Atoi(p.input[start:p.pos])
+		if err != nil || i < 1 || i > p.inputs {
+			p.pos = start - 1
+			return nil, p.errorf("unknown input (have %d inputs)", p.inputs)
+		}
+		return inputNode(i - 1), nil
+
+	case c == '.' || unicode.IsDigit(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || unicode.IsDigit(rune(p.input[p.pos]))) {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("malformed number")
+		}
+		return numberNode(f), nil
+
+	case unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		fn, ok := expressionFuncs[name]
+		if !ok {
+			p.pos = start
+			return nil, p.errorf("unknown function %q", name)
+		}
+		if p.peek() != '(' {
+			return nil, p.errorf("expected '(' after %s", name)
+		}
+		p.pos++
+		call := &callNode{fn: fn}
+		for {
+			arg, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return call, nil
+	}
+	return nil, p.errorf("unexpected %q", string(c))
+}
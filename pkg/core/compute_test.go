@@ -0,0 +1,99 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		values     []interface{}
+		expected   float64
+	}{
+		{"number", "42", []interface{}{}, 42},
+		{"input", "$1", []interface{}{20}, 20},
+		{"product", "$1 * $2", []interface{}{230, 1.5}, 345},
+		{"precedence", "$1 + $2 * 2", []interface{}{1, 2}, 5},
+		{"parentheses", "($1 + $2) * 2", []interface{}{1, 2}, 6},
+		{"left associative", "$1 - $2 - $3", []interface{}{10, 2, 3}, 5},
+		{"division", "$1 / $2 * 100", []interface{}{uint32(30), uint32(120)}, 25},
+		{"negation", "-$1 + 1", []interface{}{int64(3)}, -2},
+		{"decimal", "$1 * .5", []interface{}{10}, 5},
+		{"sum", "sum($1, $2, $3) / 1000", []interface{}{1000, 2000, uint(3000)}, 6},
+		{"avg", "avg($1, $2)", []interface{}{1, 2}, 1.5},
+		{"min", "min($1, $2, 0)", []interface{}{1, -2}, -2},
+		{"max", "max($1, $2)", []interface{}{1, 2}, 2},
+		{"nested", "max(sum($1, $2), $3)", []interface{}{1, 2, 4}, 4},
+		{"numeric string", "$1 + 1", []interface{}{"23.5"}, 24.5},
+		{"whitespace", " $1\t*\n2 ", []interface{}{2}, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := ParseExpression(test.expression, len(test.values))
+			assert.NoError(t, err)
+
+			value, err := fn(test.values)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
+
+func TestParseExpression_Error(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"empty", ""},
+		{"unknown input", "$1 + $3"},
+		{"zero input", "$0"},
+		{"missing input index", "$ + 1"},
+		{"missing operand", "$1 *"},
+		{"unbalanced parentheses", "($1 + $2"},
+		{"unknown function", "foo($1)"},
+		{"function without call", "sum + 1"},
+		{"malformed number", "1.2.3"},
+		{"trailing input", "$1 $2"},
+		{"unknown character", "$1 % $2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseExpression(test.expression, 2)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseExpression_EvalError(t *testing.T) {
+	fn, err := ParseExpression("$1 / $2", 2)
+	assert.NoError(t, err)
+
+	_, err = fn([]interface{}{1, 0})
+	assert.Equal(t, ErrDivisionByZero, err)
+
+	_, err = fn([]interface{}{1, "foo"})
+	assert.EqualError(t, err, `input $2: value "foo" is not a number`)
+
+	_, err = fn([]interface{}{1})
+	assert.Error(t, err)
+}
+
+func TestToFloat(t *testing.T) {
+	for _, value := range []interface{}{
+		int(2), int8(2), int16(2), int32(2), int64(2),
+		uint(2), uint8(2), uint16(2), uint32(2), uint64(2),
+		float32(2), float64(2), "2", " 2 ",
+	} {
+		f, err := ToFloat(value)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(2), f)
+	}
+
+	_, err := ToFloat([]byte("2"))
+	assert.Error(t, err)
+}
@@ -1,6 +1,9 @@
 package core
 
 import (
+	"fmt"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -12,12 +15,242 @@ import (
 // which are defined in the plugin config's dynamicRegistration block.
 type SnmpTargetConfiguration struct {
 	MIB       string          `yaml:"mib,omitempty"`
+	MIBs      []string        `yaml:"mibs,omitempty"`
 	Version   string          `yaml:"version,omitempty"`
 	Agent     string          `yaml:"agent,omitempty"`
 	Community string          `yaml:"community,omitempty"`
 	Timeout   time.Duration   `yaml:"timeout,omitempty"`
 	Retries   int             `yaml:"retries,omitempty"`
 	Security  *SnmpV3Security `yaml:"security,omitempty"`
+
+	Discovery SnmpDiscoveryConfiguration `yaml:"discovery,omitempty"`
+	Walk      SnmpWalkConfiguration      `yaml:"walk,omitempty"`
+	Status    SnmpStatusConfiguration    `yaml:"status,omitempty"`
+	Identity  SnmpIdentityConfiguration  `yaml:"identity,omitempty"`
+}
+
+// Modes for identifying an SNMP agent in the IDs of its devices.
+const (
+	// IdentityAgent identifies the agent by its address, as configured.
+	IdentityAgent = "agent"
+
+	// IdentityCanonical identifies the agent by the canonical form of its
+	// address (see CanonicalAgent).
+	IdentityCanonical = "canonical"
+
+	// IdentityEngineID identifies the agent by its snmpEngineID.
+	IdentityEngineID = "engine-id"
+
+	// IdentitySysNameSerial identifies the agent by its sysName and serial
+	// number.
+	IdentitySysNameSerial = "sysname-serial"
+)
+
+// DefaultIDTemplate is the default template for the IDs of an SNMP target's devices,
+// which identifies a device by its agent, MIB and OID.
+const DefaultIDTemplate = "{{.Identity}}-{{.MIB}}:{{.OID}}"
+
+// SnmpIdentityConfiguration defines how the devices of an SNMP target are identified.
+type SnmpIdentityConfiguration struct {
+	// Mode is how the agent is identified (IdentityAgent, IdentityCanonical,
+	// IdentityEngineID or IdentitySysNameSerial). It defaults to IdentityAgent.
+	Mode string `yaml:"mode,omitempty"`
+
+	// SerialOid is the OID of the agent's serial number, for IdentitySysNameSerial.
+	// It defaults to EntPhysicalSerialNumOid.
+	SerialOid string `yaml:"serialOid,omitempty"`
+
+	// Template is the Go template for the IDs of the target's devices. It is
+	// rendered with an IDContext. It defaults to DefaultIDTemplate.
+	Template string `yaml:"template,omitempty"`
+
+	// Aliases maps previous device IDs (e.g. the Synse IDs of the devices before
+	// the identity configuration changed) to the rendered IDs of the devices which
+	// replace them. Each previous ID is set as the alias of its device, so the
+	// device can still be looked up by it.
+	Aliases map[string]string `yaml:"aliases,omitempty"`
+}
+
+// IDContext is the context the device ID template of an SNMP target is rendered with.
+type IDContext struct {
+	// Identity is the identity of the agent, as given by the identity mode.
+	Identity string
+
+	// Agent is the agent address, as configured.
+	Agent string
+
+	// MIB is the name of the MIB which defines the device.
+	MIB string
+
+	// OID is the OID of the device, or the key of a device which has no OID of
+	// its own (e.g. a computed device).
+	OID string
+}
+
+// DeviceID renders the device ID template with the given context.
+func (cfg *SnmpIdentityConfiguration) DeviceID(ctx IDContext) (string, error) {
+	text := cfg.Template
+	if text == "" {
+		text = DefaultIDTemplate
+	}
+	tmpl, err := template.New("id").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid device ID template: %v", err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", fmt.Errorf("failed to render device ID template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// RequiresAgent checks whether the identity mode requires getting the identity from
+// the agent, rather than deriving it from the configuration.
+func (cfg *SnmpIdentityConfiguration) RequiresAgent() bool {
+	return cfg.Mode == IdentityEngineID || cfg.Mode == IdentitySysNameSerial
+}
+
+// SnmpStatusConfiguration defines the status device of an SNMP target, which reports
+// the reachability and latency of the target's agent.
+type SnmpStatusConfiguration struct {
+	// Enabled registers a status device for the target.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// ProbeInterval is the interval at which the agent's sysUpTime is probed
+	// while there is no other traffic to the agent, so its status stays up to
+	// date. If not set, a default of 30s is used.
+	ProbeInterval time.Duration `yaml:"probeInterval,omitempty"`
+}
+
+// SnmpWalkConfiguration defines how the subtrees of an SNMP agent are walked.
+type SnmpWalkConfiguration struct {
+	// Mode is the walk mode (WalkAuto, WalkBulk or WalkGetNext). By default,
+	// the mode is WalkAuto.
+	Mode string `yaml:"mode,omitempty"`
+
+	// MaxOids is the maximum number of OIDs a single walk receives, including
+	// OIDs in excluded subtrees. A walk which reaches it ends early with
+	// partial results. There is no maximum if this is not set.
+	MaxOids int `yaml:"maxOids,omitempty"`
+
+	// MaxDuration is the maximum duration of a single walk. A walk which
+	// exceeds it ends early with partial results. There is no maximum if
+	// this is not set.
+	MaxDuration time.Duration `yaml:"maxDuration,omitempty"`
+
+	// Resumes is the number of times a walk resumes from the last received
+	// OID (or retries its first request) after a request fails. If not set,
+	// walks resume twice; a negative value disables resuming.
+	Resumes int `yaml:"resumes,omitempty"`
+
+	// Exclude are the root OIDs of subtrees which are skipped when walking.
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	// MaxRepetitions is the GETBULK max-repetitions used when walking. If not
+	// set, a default of 50 is used.
+	MaxRepetitions uint8 `yaml:"maxRepetitions,omitempty"`
+
+	// NonRepeaters is the GETBULK non-repeaters used when walking. Since walks
+	// request a single OID at a time, this should generally not be set.
+	NonRepeaters int `yaml:"nonRepeaters,omitempty"`
+}
+
+// Strategies for discovering the devices which an SNMP agent supports.
+const (
+	// DiscoveryWalk discovers supported devices by walking each MIB root.
+	DiscoveryWalk = "walk"
+
+	// DiscoveryProbe discovers supported devices by getting exactly the
+	// OIDs of the MIB's devices, and probing the MIB's table columns with
+	// GETNEXT, instead of walking the entire MIB root.
+	DiscoveryProbe = "probe"
+)
+
+// Policies for devices whose discovered value does not have the type the device
+// expects.
+const (
+	// TypeMismatchWarn logs and reports the mismatch, but otherwise handles the
+	// device as usual.
+	TypeMismatchWarn = "warn"
+
+	// TypeMismatchSkip logs and reports the mismatch, and treats the device as
+	// not supported by the agent.
+	TypeMismatchSkip = "skip"
+
+	// TypeMismatchDecode logs and reports the mismatch, and reads the device
+	// with a decoder which converts the value to the expected type, if there
+	// is one (see DecoderNumericString and DecoderString).
+	TypeMismatchDecode = "decode"
+)
+
+// Policies for targets whose agent does not support all of the required devices
+// of its MIBs.
+const (
+	// MissingRequiredFail fails discovery for the target.
+	MissingRequiredFail = "fail"
+
+	// MissingRequiredDegrade registers the target's devices as usual, but
+	// marks the target as degraded.
+	MissingRequiredDegrade = "degrade"
+)
+
+// SnmpDiscoveryConfiguration defines how devices are discovered for an SNMP target.
+type SnmpDiscoveryConfiguration struct {
+	// Deferred enables deferred registration. If the agent cannot be reached
+	// at startup, its devices are registered from the MIB definitions and
+	// discovery is retried in the background until the agent answers.
+	Deferred bool `yaml:"deferred,omitempty"`
+
+	// RetryInterval is the initial delay between background discovery attempts
+	// for a deferred target. The delay doubles after each failed attempt.
+	RetryInterval time.Duration `yaml:"retryInterval,omitempty"`
+
+	// MaxRetryInterval is the maximum delay between background discovery
+	// attempts for a deferred target.
+	MaxRetryInterval time.Duration `yaml:"maxRetryInterval,omitempty"`
+
+	// Interval is the interval at which the agent is periodically rediscovered,
+	// so devices which are added to or removed from the agent are picked up.
+	// Rediscovery is disabled if this is not set.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Timeout is the deadline for a single discovery attempt for the target,
+	// covering connecting to the agent and walking its MIB roots. There is no
+	// deadline beyond the SNMP request timeouts if this is not set.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// Strategy is the discovery strategy used for all MIBs of the target
+	// (DiscoveryWalk or DiscoveryProbe). If not set, each MIB's discovery
+	// strategy is used.
+	Strategy string `yaml:"strategy,omitempty"`
+
+	// TypeMismatch is the policy for devices whose discovered value does not
+	// have the type the device expects (TypeMismatchWarn, TypeMismatchSkip or
+	// TypeMismatchDecode). It defaults to TypeMismatchWarn.
+	TypeMismatch string `yaml:"typeMismatch,omitempty"`
+
+	// MissingRequired is the policy for when the agent does not support all of
+	// the required devices of the target's MIBs (MissingRequiredFail or
+	// MissingRequiredDegrade). It defaults to MissingRequiredFail.
+	MissingRequired string `yaml:"missingRequired,omitempty"`
+}
+
+// MIBNames gets the names of all MIBs configured for the target, combining the
+// single "mib" field with the "mibs" list. Duplicate names are only included once.
+func (cfg *SnmpTargetConfiguration) MIBNames() []string {
+	var names []string
+	seen := map[string]struct{}{}
+	for _, name := range append([]string{cfg.MIB}, cfg.MIBs...) {
+		if name == "" {
+			continue
+		}
+		if _, exists := seen[name]; exists {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
 }
 
 // SnmpV3Security defines the security configuration for the SNMP connection. Only
@@ -72,6 +305,98 @@ func LoadTargetConfiguration(raw map[string]interface{}) (*SnmpTargetConfigurati
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 3 * time.Second
 	}
+	if cfg.Discovery.RetryInterval == 0 {
+		cfg.Discovery.RetryInterval = 10 * time.Second
+	}
+	if cfg.Discovery.MaxRetryInterval == 0 {
+		cfg.Discovery.MaxRetryInterval = 5 * time.Minute
+	}
+
+	switch cfg.Discovery.Strategy {
+	case "", DiscoveryWalk, DiscoveryProbe:
+	default:
+		log.WithFields(log.Fields{
+			"strategy": cfg.Discovery.Strategy,
+		}).Error("[snmp] unsupported discovery strategy")
+		return nil, fmt.Errorf("unsupported discovery strategy: %s", cfg.Discovery.Strategy)
+	}
+
+	switch cfg.Discovery.TypeMismatch {
+	case "":
+		cfg.Discovery.TypeMismatch = TypeMismatchWarn
+	case TypeMismatchWarn, TypeMismatchSkip, TypeMismatchDecode:
+	default:
+		log.WithFields(log.Fields{
+			"policy": cfg.Discovery.TypeMismatch,
+		}).Error("[snmp] unsupported type mismatch policy")
+		return nil, fmt.Errorf("unsupported type mismatch policy: %s", cfg.Discovery.TypeMismatch)
+	}
+
+	switch cfg.Discovery.MissingRequired {
+	case "":
+		cfg.Discovery.MissingRequired = MissingRequiredFail
+	case MissingRequiredFail, MissingRequiredDegrade:
+	default:
+		log.WithFields(log.Fields{
+			"policy": cfg.Discovery.MissingRequired,
+		}).Error("[snmp] unsupported missing required device policy")
+		return nil, fmt.Errorf("unsupported missing required device policy: %s", cfg.Discovery.MissingRequired)
+	}
+
+	if cfg.Status.ProbeInterval == 0 {
+		cfg.Status.ProbeInterval = 30 * time.Second
+	}
+
+	switch cfg.Identity.Mode {
+	case "":
+		cfg.Identity.Mode = IdentityAgent
+	case IdentityAgent, IdentityCanonical, IdentityEngineID, IdentitySysNameSerial:
+	default:
+		log.WithFields(log.Fields{
+			"mode": cfg.Identity.Mode,
+		}).Error("[snmp] unsupported identity mode")
+		return nil, fmt.Errorf("unsupported identity mode: %s", cfg.Identity.Mode)
+	}
+	if cfg.Identity.SerialOid == "" {
+		cfg.Identity.SerialOid = EntPhysicalSerialNumOid
+	}
+	if cfg.Identity.Template == "" {
+		cfg.Identity.Template = DefaultIDTemplate
+	}
+	if _, err := template.New("id").Option("missingkey=error").Parse(cfg.Identity.Template); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"template": cfg.Identity.Template,
+		}).Error("[snmp] invalid device ID template")
+		return nil, fmt.Errorf("invalid device ID template: %v", err)
+	}
+	// A device can only have a single alias, so only one previous device ID may
+	// be aliased to each device ID.
+	aliased := map[string]string{}
+	for previous, current := range cfg.Identity.Aliases {
+		if other, exists := aliased[current]; exists {
+			log.WithFields(log.Fields{
+				"id":       current,
+				"previous": []string{other, previous},
+			}).Error("[snmp] multiple device IDs aliased to the same device")
+			return nil, fmt.Errorf("invalid device ID aliases: %s is aliased by multiple device IDs", current)
+		}
+		aliased[current] = previous
+	}
+
+	if cfg.Walk.Resumes == 0 {
+		cfg.Walk.Resumes = 2
+	}
+
+	switch cfg.Walk.Mode {
+	case "":
+		cfg.Walk.Mode = WalkAuto
+	case WalkAuto, WalkBulk, WalkGetNext:
+	default:
+		log.WithFields(log.Fields{
+			"mode": cfg.Walk.Mode,
+		}).Error("[snmp] unsupported walk mode")
+		return nil, fmt.Errorf("unsupported walk mode: %s", cfg.Walk.Mode)
+	}
 
 	return &cfg, nil
 }
@@ -87,6 +87,19 @@ func TestLoadTargetConfiguration_defaults(t *testing.T) {
 	assert.Equal(t, "", cfg.Community)
 	assert.Equal(t, 3*time.Second, cfg.Timeout)
 	assert.Equal(t, 1, cfg.Retries)
+	assert.False(t, cfg.Discovery.Deferred)
+	assert.Equal(t, 10*time.Second, cfg.Discovery.RetryInterval)
+	assert.Equal(t, 5*time.Minute, cfg.Discovery.MaxRetryInterval)
+	assert.Equal(t, time.Duration(0), cfg.Discovery.Interval)
+	assert.Equal(t, TypeMismatchWarn, cfg.Discovery.TypeMismatch)
+	assert.Equal(t, MissingRequiredFail, cfg.Discovery.MissingRequired)
+	assert.Equal(t, WalkAuto, cfg.Walk.Mode)
+	assert.Equal(t, 2, cfg.Walk.Resumes)
+	assert.False(t, cfg.Status.Enabled)
+	assert.Equal(t, 30*time.Second, cfg.Status.ProbeInterval)
+	assert.Equal(t, IdentityAgent, cfg.Identity.Mode)
+	assert.Equal(t, EntPhysicalSerialNumOid, cfg.Identity.SerialOid)
+	assert.Equal(t, DefaultIDTemplate, cfg.Identity.Template)
 
 	security := cfg.Security
 	assert.NotNil(t, security)
@@ -128,3 +141,285 @@ func TestLoadTargetConfiguration_BadData(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, cfg)
 }
+
+func TestLoadTargetConfiguration_MultipleMIBs(t *testing.T) {
+	c := map[string]interface{}{
+		"mibs":    []interface{}{"mib-1", "mib-2"},
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	assert.Equal(t, "", cfg.MIB)
+	assert.Equal(t, []string{"mib-1", "mib-2"}, cfg.MIBs)
+}
+
+func TestSnmpTargetConfiguration_MIBNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      SnmpTargetConfiguration
+		expected []string
+	}{
+		{
+			name:     "no MIBs",
+			cfg:      SnmpTargetConfiguration{},
+			expected: nil,
+		},
+		{
+			name:     "single MIB",
+			cfg:      SnmpTargetConfiguration{MIB: "mib-1"},
+			expected: []string{"mib-1"},
+		},
+		{
+			name:     "MIB list",
+			cfg:      SnmpTargetConfiguration{MIBs: []string{"mib-1", "mib-2"}},
+			expected: []string{"mib-1", "mib-2"},
+		},
+		{
+			name:     "single MIB and list with duplicate",
+			cfg:      SnmpTargetConfiguration{MIB: "mib-2", MIBs: []string{"mib-1", "mib-2"}},
+			expected: []string{"mib-2", "mib-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.cfg.MIBNames())
+		})
+	}
+}
+
+func TestLoadTargetConfiguration_Discovery(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"discovery": map[string]interface{}{
+			"deferred":         true,
+			"retryInterval":    "30s",
+			"maxRetryInterval": "10m",
+			"interval":         "1h",
+			"timeout":          "30s",
+			"strategy":         "probe",
+			"typeMismatch":     "decode",
+			"missingRequired":  "degrade",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	assert.True(t, cfg.Discovery.Deferred)
+	assert.Equal(t, 30*time.Second, cfg.Discovery.RetryInterval)
+	assert.Equal(t, 10*time.Minute, cfg.Discovery.MaxRetryInterval)
+	assert.Equal(t, time.Hour, cfg.Discovery.Interval)
+	assert.Equal(t, 30*time.Second, cfg.Discovery.Timeout)
+	assert.Equal(t, DiscoveryProbe, cfg.Discovery.Strategy)
+	assert.Equal(t, TypeMismatchDecode, cfg.Discovery.TypeMismatch)
+	assert.Equal(t, MissingRequiredDegrade, cfg.Discovery.MissingRequired)
+}
+
+func TestLoadTargetConfiguration_Walk(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v1",
+		"agent":   "udp://localhost:1024",
+		"walk": map[string]interface{}{
+			"mode":           "getnext",
+			"maxOids":        1000,
+			"maxDuration":    "1m",
+			"resumes":        -1,
+			"exclude":        []string{"1.3.6.1.4.1.534.1.9"},
+			"maxRepetitions": 10,
+			"nonRepeaters":   1,
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.NoError(t, err)
+	assert.Equal(t, WalkGetNext, cfg.Walk.Mode)
+	assert.Equal(t, 1000, cfg.Walk.MaxOids)
+	assert.Equal(t, time.Minute, cfg.Walk.MaxDuration)
+	assert.Equal(t, -1, cfg.Walk.Resumes)
+	assert.Equal(t, []string{"1.3.6.1.4.1.534.1.9"}, cfg.Walk.Exclude)
+	assert.Equal(t, uint8(10), cfg.Walk.MaxRepetitions)
+	assert.Equal(t, 1, cfg.Walk.NonRepeaters)
+}
+
+func TestLoadTargetConfiguration_Status(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v1",
+		"agent":   "udp://localhost:1024",
+		"status": map[string]interface{}{
+			"enabled":       true,
+			"probeInterval": "10s",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.NoError(t, err)
+	assert.True(t, cfg.Status.Enabled)
+	assert.Equal(t, 10*time.Second, cfg.Status.ProbeInterval)
+}
+
+func TestLoadTargetConfiguration_Identity(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"identity": map[string]interface{}{
+			"mode":     "engine-id",
+			"template": "{{.Identity}}/{{.OID}}",
+			"aliases": map[string]interface{}{
+				"udp://localhost:1024-test-mib:1.2.3.4": "8000000001/1.2.3.4",
+			},
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.NoError(t, err)
+	assert.Equal(t, IdentityEngineID, cfg.Identity.Mode)
+	assert.True(t, cfg.Identity.RequiresAgent())
+	assert.Equal(t, "{{.Identity}}/{{.OID}}", cfg.Identity.Template)
+	assert.Equal(t, map[string]string{
+		"udp://localhost:1024-test-mib:1.2.3.4": "8000000001/1.2.3.4",
+	}, cfg.Identity.Aliases)
+}
+
+func TestLoadTargetConfiguration_BadIdentityMode(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"identity": map[string]interface{}{
+			"mode": "mac",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.EqualError(t, err, "unsupported identity mode: mac")
+	assert.Nil(t, cfg)
+}
+
+func TestLoadTargetConfiguration_BadIDTemplate(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"identity": map[string]interface{}{
+			"template": "{{.Identity",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid device ID template")
+	assert.Nil(t, cfg)
+}
+
+func TestLoadTargetConfiguration_BadIDAliases(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"identity": map[string]interface{}{
+			"aliases": map[string]interface{}{
+				"old-1": "new",
+				"old-2": "new",
+			},
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.EqualError(t, err, "invalid device ID aliases: new is aliased by multiple device IDs")
+	assert.Nil(t, cfg)
+}
+
+func TestSnmpIdentityConfiguration_DeviceID(t *testing.T) {
+	ctx := IDContext{
+		Identity: "udp://10.0.0.5:161",
+		Agent:    "10.0.0.5",
+		MIB:      "test-mib",
+		OID:      "1.2.3.4",
+	}
+
+	// The default template gives the same IDs as the agent address alone.
+	cfg := SnmpIdentityConfiguration{}
+	id, err := cfg.DeviceID(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "udp://10.0.0.5:161-test-mib:1.2.3.4", id)
+
+	cfg.Template = "rack-1/{{.MIB}}/{{.OID}}"
+	id, err = cfg.DeviceID(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "rack-1/test-mib/1.2.3.4", id)
+
+	cfg.Template = "{{.Serial}}"
+	_, err = cfg.DeviceID(ctx)
+	assert.Error(t, err)
+}
+
+func TestLoadTargetConfiguration_BadWalkMode(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"walk": map[string]interface{}{
+			"mode": "fast",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.EqualError(t, err, "unsupported walk mode: fast")
+	assert.Nil(t, cfg)
+}
+
+func TestLoadTargetConfiguration_BadDiscoveryStrategy(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"discovery": map[string]interface{}{
+			"strategy": "scan",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.EqualError(t, err, "unsupported discovery strategy: scan")
+	assert.Nil(t, cfg)
+}
+
+func TestLoadTargetConfiguration_BadTypeMismatchPolicy(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"discovery": map[string]interface{}{
+			"typeMismatch": "ignore",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.EqualError(t, err, "unsupported type mismatch policy: ignore")
+	assert.Nil(t, cfg)
+}
+
+func TestLoadTargetConfiguration_BadMissingRequiredPolicy(t *testing.T) {
+	c := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "udp://localhost:1024",
+		"discovery": map[string]interface{}{
+			"missingRequired": "ignore",
+		},
+	}
+
+	cfg, err := LoadTargetConfiguration(c)
+	assert.EqualError(t, err, "unsupported missing required device policy: ignore")
+	assert.Nil(t, cfg)
+}
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/soniah/gosnmp"
+)
+
+// Reachability states of an SNMP agent.
+const (
+	// AgentUnknown indicates that no request has been made to the agent yet.
+	AgentUnknown = "unknown"
+
+	// AgentReachable indicates that the agent answered the most recent request.
+	AgentReachable = "reachable"
+
+	// AgentUnreachable indicates that the most recent request to the agent failed.
+	AgentUnreachable = "unreachable"
+)
+
+// latencyWindow is the number of round trips the rolling latency of an agent is
+// averaged over.
+const latencyWindow = 10
+
+// uptimeTolerance is how far, in hundredths of a second, the sysUpTime of an agent
+// may fall behind the uptime expected from the previously observed uptime before
+// the agent is considered to have restarted. It allows for agents whose clocks
+// drift from the plugin's.
+const uptimeTolerance = 30 * 100
+
+// uptimeWrap is the number of ticks after which sysUpTime (a TimeTicks value) wraps
+// around to zero, about 497 days after the agent started.
+const uptimeWrap = 1 << 32
+
+// HealthStatus is a snapshot of the health of an SNMP agent, as observed from the
+// requests made to it.
+type HealthStatus struct {
+	// State is the reachability state of the agent (AgentUnknown, AgentReachable
+	// or AgentUnreachable).
+	State string
+
+	// LastResponse is the time of the most recent response from the agent.
+	LastResponse time.Time
+
+	// LastRequest is the time of the most recent request to the agent, whether
+	// or not it succeeded.
+	LastRequest time.Time
+
+	// LastError is the error of the most recent failed request. It is cleared
+	// when the agent responds again.
+	LastError string
+
+	// Failures is the number of consecutive failed requests.
+	Failures int
+
+	// Latency is the average round-trip time of the agent's most recent
+	// responses. It is zero until the agent responds.
+	Latency time.Duration
+
+	// Uptime is the most recently observed sysUpTime of the agent, in hundredths
+	// of a second.
+	Uptime uint32
+
+	// EngineBoots is the most recently observed snmpEngineBoots of the agent.
+	// It is only observed for SNMPv3 agents.
+	EngineBoots uint32
+
+	// Restarts is the number of times the agent was detected to have restarted.
+	Restarts int
+
+	// LastRestart is the time at which the agent was last detected to have
+	// restarted.
+	LastRestart time.Time
+}
+
+// AgentHealth tracks the health of an SNMP agent from the requests made to it. The
+// health of a target's agent is owned by the target, and is updated by every client
+// created for the target (see Target.NewClient), so it reflects all of the target's
+// traffic to the agent. It is safe for concurrent use.
+type AgentHealth struct {
+	mu     sync.RWMutex
+	agent  string
+	status HealthStatus
+
+	latencies [latencyWindow]time.Duration
+	samples   int
+
+	hasUptime  bool
+	uptimeSeen time.Time
+	hasBoots   bool
+
+	subscribers []chan struct{}
+}
+
+// NewAgentHealth creates the health of the agent with the given (configured)
+// address. Nothing is known about the agent until requests to it are observed.
+func NewAgentHealth(agent string) *AgentHealth {
+	return &AgentHealth{agent: agent, status: HealthStatus{State: AgentUnknown}}
+}
+
+// Observe records the outcome of a request to the agent and its round-trip time.
+// A request which returned an error is a failed request; its round-trip time is
+// not included in the rolling latency.
+func (h *AgentHealth) Observe(rtt time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.status.LastRequest = now
+	if err != nil {
+		h.status.State = AgentUnreachable
+		h.status.LastError = err.Error()
+		h.status.Failures++
+		return
+	}
+
+	h.status.State = AgentReachable
+	h.status.LastResponse = now
+	h.status.LastError = ""
+	h.status.Failures = 0
+
+	h.latencies[h.samples%latencyWindow] = rtt
+	h.samples++
+}
+
+// Status gets a snapshot of the agent's health.
+func (h *AgentHealth) Status() HealthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := h.status
+	n := h.samples
+	if n > latencyWindow {
+		n = latencyWindow
+	}
+	if n != 0 {
+		var total time.Duration
+		for _, l := range h.latencies[:n] {
+			total += l
+		}
+		status.Latency = total / time.Duration(n)
+	}
+	return status
+}
+
+// Idle checks whether no request has been made to the agent within the given
+// duration.
+func (h *AgentHealth) Idle(d time.Duration) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return time.Since(h.status.LastRequest) >= d
+}
+
+// ObserveUptime records the sysUpTime of the agent, in hundredths of a second. If
+// the uptime is lower than expected from the previously observed uptime and the
+// time elapsed since, the agent has restarted (see Subscribe) and true is returned.
+// The uptime wrapping around to zero is not considered a restart.
+func (h *AgentHealth) ObserveUptime(ticks uint32) bool {
+	return h.observeAgent(&ticks, nil)
+}
+
+// ObserveEngineBoots records the snmpEngineBoots of an SNMPv3 agent. If it is
+// higher than the previously observed value, the agent has restarted (see
+// Subscribe) and true is returned.
+func (h *AgentHealth) ObserveEngineBoots(boots uint32) bool {
+	return h.observeAgent(nil, &boots)
+}
+
+// Subscribe gets a channel on which a notification is sent whenever the agent is
+// detected to have restarted, e.g. so that components which depend on the agent's
+// counters or discovered devices can reset them. Multiple restarts collapse into a
+// single pending notification. The returned function unsubscribes the channel, so
+// it no longer receives notifications.
+func (h *AgentHealth) Subscribe() (<-chan struct{}, func()) {
+	ch := h.subscribe()
+	return ch, func() { h.unsubscribe(ch) }
+}
+
+// subscribe creates a restart notification channel for the agent (see Subscribe).
+func (h *AgentHealth) subscribe() chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	h.subscribers = append(h.subscribers, ch)
+	return ch
+}
+
+// unsubscribe removes a restart notification channel of the agent.
+func (h *AgentHealth) unsubscribe(ch chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, sub := range h.subscribers {
+		if sub == ch {
+			h.subscribers = append(h.subscribers[:i], h.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// observeResponse records the sysUpTime and snmpEngineBoots of the agent, if the
+// response holds them.
+func (h *AgentHealth) observeResponse(response *gosnmp.SnmpPacket) {
+	if response == nil {
+		return
+	}
+	var ticks, boots *uint32
+	for _, v := range response.Variables {
+		if NormalizeOid(v.Name) != SysUpTimeOid {
+			continue
+		}
+		if t, ok := v.Value.(uint32); ok {
+			ticks = &t
+		}
+	}
+	if sp, ok := response.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok && response.Version == gosnmp.Version3 {
+		b := sp.AuthoritativeEngineBoots
+		boots = &b
+	}
+	if ticks != nil || boots != nil {
+		h.observeAgent(ticks, boots)
+	}
+}
+
+// observeAgent records the sysUpTime and snmpEngineBoots of the agent, either of
+// which may be nil, and checks whether the agent has restarted. A restart which is
+// seen in both values is only counted once.
+func (h *AgentHealth) observeAgent(ticks, boots *uint32) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	var reasons []string
+	prevUptime := h.status.Uptime
+
+	if boots != nil {
+		if h.hasBoots && *boots > h.status.EngineBoots {
+			reasons = append(reasons, "snmpEngineBoots")
+			// The uptime from before the restart is no longer comparable.
+			h.hasUptime = false
+		}
+		h.status.EngineBoots = *boots
+		h.hasBoots = true
+	}
+
+	if ticks != nil {
+		if h.hasUptime {
+			// The uptime's advance is computed modulo the wrap, so an uptime
+			// which wrapped around to zero has advanced as usual. An uptime
+			// which went backwards, or advanced less than the time elapsed, is
+			// a restart.
+			elapsed := uint64(now.Sub(h.uptimeSeen) / (10 * time.Millisecond))
+			advance := uint64(*ticks - h.status.Uptime)
+			if advance >= uptimeWrap/2 || advance+uptimeTolerance < elapsed {
+				reasons = append(reasons, "sysUpTime")
+			}
+		}
+		h.status.Uptime = *ticks
+		h.uptimeSeen = now
+		h.hasUptime = true
+	}
+
+	if len(reasons) == 0 {
+		return false
+	}
+
+	h.status.Restarts++
+	h.status.LastRestart = now
+	log.WithFields(log.Fields{
+		"agent":       h.agent,
+		"detectedBy":  reasons,
+		"uptime":      h.status.Uptime,
+		"prevUptime":  prevUptime,
+		"engineBoots": h.status.EngineBoots,
+		"restarts":    h.status.Restarts,
+	}).Warn("[snmp] agent restart detected")
+
+	for _, ch := range h.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
@@ -0,0 +1,285 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+)
+
+func TestNewAgentHealth(t *testing.T) {
+	h := NewAgentHealth("localhost")
+
+	status := h.Status()
+	assert.Equal(t, AgentUnknown, status.State)
+	assert.True(t, status.LastResponse.IsZero())
+	assert.Equal(t, time.Duration(0), status.Latency)
+	assert.True(t, h.Idle(time.Hour))
+}
+
+func TestAgentHealth_Observe(t *testing.T) {
+	h := NewAgentHealth("localhost")
+
+	h.Observe(10*time.Millisecond, nil)
+	status := h.Status()
+	assert.Equal(t, AgentReachable, status.State)
+	assert.False(t, status.LastResponse.IsZero())
+	assert.Equal(t, status.LastResponse, status.LastRequest)
+	assert.Equal(t, 10*time.Millisecond, status.Latency)
+	assert.False(t, h.Idle(time.Minute))
+
+	h.Observe(time.Second, errors.New("request timeout"))
+	h.Observe(time.Second, errors.New("request timeout"))
+	status = h.Status()
+	assert.Equal(t, AgentUnreachable, status.State)
+	assert.Equal(t, "request timeout", status.LastError)
+	assert.Equal(t, 2, status.Failures)
+	assert.True(t, status.LastRequest.After(status.LastResponse))
+	// Failed requests do not count towards the latency.
+	assert.Equal(t, 10*time.Millisecond, status.Latency)
+
+	h.Observe(20*time.Millisecond, nil)
+	status = h.Status()
+	assert.Equal(t, AgentReachable, status.State)
+	assert.Equal(t, "", status.LastError)
+	assert.Equal(t, 0, status.Failures)
+	assert.Equal(t, 15*time.Millisecond, status.Latency)
+}
+
+func TestAgentHealth_Latency_Rolling(t *testing.T) {
+	h := NewAgentHealth("localhost")
+
+	for i := 0; i < latencyWindow; i++ {
+		h.Observe(100*time.Millisecond, nil)
+	}
+	assert.Equal(t, 100*time.Millisecond, h.Status().Latency)
+
+	// Older round trips fall out of the window.
+	for i := 0; i < latencyWindow; i++ {
+		h.Observe(10*time.Millisecond, nil)
+	}
+	assert.Equal(t, 10*time.Millisecond, h.Status().Latency)
+}
+
+func TestClient_Health(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	// Only clients created for a target track the agent's health.
+	plain := newTestClient(t, agent, "v2", SnmpWalkConfiguration{})
+	defer plain.Close()
+	assert.Nil(t, plain.Health())
+
+	target, client := newTestTargetClient(t, agent)
+	defer client.Close()
+	assert.Same(t, target.Health(), client.Health())
+	assert.Equal(t, AgentUnknown, client.Health().Status().State)
+
+	_, err := client.GetOids("1.2.3.1.0")
+	assert.NoError(t, err)
+	status := client.Health().Status()
+	assert.Equal(t, AgentReachable, status.State)
+	assert.False(t, status.LastResponse.IsZero())
+	assert.True(t, status.Latency > 0)
+
+	_, err = client.WalkSubtree("1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, AgentReachable, client.Health().Status().State)
+
+	agent.Close()
+	_, err = client.GetOids("1.2.3.1.0")
+	assert.Error(t, err)
+	status = client.Health().Status()
+	assert.Equal(t, AgentUnreachable, status.State)
+	assert.NotEqual(t, "", status.LastError)
+}
+
+func TestAgentHealth_ObserveUptime(t *testing.T) {
+	h := NewAgentHealth("localhost")
+	restarts, _ := h.Subscribe()
+
+	assert.False(t, h.ObserveUptime(100))
+	assert.False(t, h.ObserveUptime(200))
+	assert.True(t, h.ObserveUptime(10))
+
+	status := h.Status()
+	assert.Equal(t, uint32(10), status.Uptime)
+	assert.Equal(t, 1, status.Restarts)
+	assert.False(t, status.LastRestart.IsZero())
+
+	select {
+	case <-restarts:
+	default:
+		t.Fatal("expected restart notification")
+	}
+}
+
+func TestAgentHealth_Subscribe_Unsubscribe(t *testing.T) {
+	h := NewAgentHealth("localhost")
+	restarts, unsubscribe := h.Subscribe()
+	other, _ := h.Subscribe()
+
+	// Once unsubscribed, the channel no longer receives notifications, while
+	// other subscribers still do.
+	unsubscribe()
+	assert.False(t, h.ObserveUptime(200))
+	assert.True(t, h.ObserveUptime(100))
+	select {
+	case <-restarts:
+		t.Fatal("unexpected restart notification")
+	default:
+	}
+	select {
+	case <-other:
+	default:
+		t.Fatal("expected restart notification")
+	}
+}
+
+func TestAgentHealth_Separate(t *testing.T) {
+	// Targets for the same agent do not share their agent's health.
+	config := &SnmpTargetConfiguration{Agent: "localhost"}
+	a, b := NewTarget(config, nil), NewTarget(config, nil)
+	a.Health().Observe(10*time.Millisecond, nil)
+	a.ObserveUptime(200)
+	a.ObserveUptime(100)
+
+	assert.Equal(t, AgentReachable, a.Status().Health.State)
+	assert.Equal(t, 1, a.Status().Health.Restarts)
+	assert.Equal(t, AgentUnknown, b.Status().Health.State)
+	assert.Equal(t, 0, b.Status().Health.Restarts)
+	select {
+	case <-b.Restarts():
+		t.Fatal("unexpected restart notification")
+	default:
+	}
+}
+
+func TestAgentHealth_ObserveUptime_Elapsed(t *testing.T) {
+	h := NewAgentHealth("localhost")
+	assert.False(t, h.ObserveUptime(1000))
+
+	// An hour later, the agent has been up for less than an hour, so it must
+	// have restarted in between, even though its uptime did not decrease.
+	h.uptimeSeen = h.uptimeSeen.Add(-time.Hour)
+	assert.True(t, h.ObserveUptime(2000))
+
+	// Small differences in the elapsed time are tolerated.
+	h.uptimeSeen = h.uptimeSeen.Add(-time.Minute)
+	assert.False(t, h.ObserveUptime(2000+60*100-1000))
+	assert.Equal(t, 1, h.Status().Restarts)
+}
+
+func TestAgentHealth_ObserveUptime_Wrap(t *testing.T) {
+	h := NewAgentHealth("localhost")
+	assert.False(t, h.ObserveUptime(uptimeWrap-100))
+
+	// The uptime wraps around to zero after about 497 days.
+	h.uptimeSeen = h.uptimeSeen.Add(-2 * time.Second)
+	assert.False(t, h.ObserveUptime(100))
+	assert.Equal(t, 0, h.Status().Restarts)
+
+	// The uptime may wrap before the elapsed time says it should, e.g. if the
+	// agent's clock runs slightly fast.
+	h2 := NewAgentHealth("localhost")
+	assert.False(t, h2.ObserveUptime(uptimeWrap-10))
+	assert.False(t, h2.ObserveUptime(10))
+	assert.Equal(t, 0, h2.Status().Restarts)
+}
+
+func TestAgentHealth_ObserveEngineBoots(t *testing.T) {
+	h := NewAgentHealth("localhost")
+	restarts, _ := h.Subscribe()
+
+	assert.False(t, h.ObserveEngineBoots(3))
+	assert.False(t, h.ObserveEngineBoots(3))
+	assert.False(t, h.ObserveUptime(500000))
+	assert.True(t, h.ObserveEngineBoots(4))
+	<-restarts
+
+	// The uptime from before the restart is not compared to the uptime after
+	// it, so the restart is only counted once.
+	assert.False(t, h.ObserveUptime(100))
+
+	status := h.Status()
+	assert.Equal(t, uint32(4), status.EngineBoots)
+	assert.Equal(t, 1, status.Restarts)
+}
+
+func TestAgentHealth_observeResponse(t *testing.T) {
+	h := NewAgentHealth("localhost")
+
+	response := func(ticks, boots uint32) *gosnmp.SnmpPacket {
+		return &gosnmp.SnmpPacket{
+			Version:            gosnmp.Version3,
+			SecurityParameters: &gosnmp.UsmSecurityParameters{AuthoritativeEngineBoots: boots},
+			Variables: []gosnmp.SnmpPDU{
+				{Name: "1.2.3.4", Type: gosnmp.Integer, Value: 1},
+				{Name: "." + SysUpTimeOid, Type: gosnmp.TimeTicks, Value: ticks},
+			},
+		}
+	}
+
+	h.observeResponse(response(500000, 7))
+	status := h.Status()
+	assert.Equal(t, uint32(500000), status.Uptime)
+	assert.Equal(t, uint32(7), status.EngineBoots)
+
+	// A restart seen in both values is only counted once.
+	h.observeResponse(response(100, 8))
+	assert.Equal(t, 1, h.Status().Restarts)
+
+	// Responses without either value are ignored.
+	h.observeResponse(&gosnmp.SnmpPacket{Version: gosnmp.Version2c})
+	h.observeResponse(nil)
+	assert.Equal(t, uint32(100), h.Status().Uptime)
+}
+
+func TestClient_Health_Restart(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(500000)})
+
+	_, client := newTestTargetClient(t, agent)
+	defer client.Close()
+	restarts, _ := client.Health().Subscribe()
+
+	ticks, err := client.GetUptime()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(500000), ticks)
+	assert.Equal(t, uint32(500000), client.Health().Status().Uptime)
+
+	agent.Set(gosnmp.SnmpPDU{Name: SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(100)})
+	_, err = client.GetOids("1.2.3.1.0", SysUpTimeOid)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.Health().Status().Restarts)
+
+	select {
+	case <-restarts:
+	default:
+		t.Fatal("expected restart notification")
+	}
+}
+
+// newTestTargetClient creates a target for a test agent, along with a connected
+// client for the target.
+func newTestTargetClient(t *testing.T, agent *snmptest.Agent) (*Target, *Client) {
+	target := NewTarget(&SnmpTargetConfiguration{
+		Version:   "v2",
+		Agent:     agent.Addr(),
+		Community: "public",
+		Timeout:   200 * time.Millisecond,
+		Retries:   1,
+	}, nil)
+	client, err := target.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return target, client
+}
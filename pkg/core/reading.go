@@ -0,0 +1,16 @@
+package core
+
+// ReadingOid is an additional OID of a device which reports more than one reading,
+// e.g. the current and power of an outlet, along with the output its value is
+// reported as.
+type ReadingOid struct {
+	// OID is the OID whose value is reported.
+	OID string
+
+	// Output is the name of the output the value is reported as.
+	Output string
+
+	// Context is added to the context of the reading, on top of the context of
+	// the device.
+	Context map[string]string
+}
@@ -0,0 +1,416 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/soniah/gosnmp"
+)
+
+// Registration states for an SNMP target.
+const (
+	// TargetPending indicates that device discovery has not yet succeeded
+	// for the target and is being retried in the background.
+	TargetPending = "pending"
+
+	// TargetRegistered indicates that device discovery succeeded and the
+	// target's devices are registered.
+	TargetRegistered = "registered"
+
+	// TargetFailed indicates that device discovery failed for the target
+	// and will not be retried.
+	TargetFailed = "failed"
+
+	// TargetDegraded indicates that device discovery succeeded and the
+	// target's devices are registered, but the agent does not support some
+	// of the devices its MIBs require.
+	TargetDegraded = "degraded"
+)
+
+// ErrRetired is the error returned when reading a device whose OID the agent
+// supported, but no longer supports since it was rediscovered.
+var ErrRetired = errors.New("device no longer supported by agent")
+
+// seedMaxAge is the maximum age of a value seeded from discovery for it to be
+// used as a device reading.
+const seedMaxAge = 5 * time.Minute
+
+// TargetStatus is a snapshot of the device registration status of an SNMP target.
+type TargetStatus struct {
+	// Agent is the configured agent address of the target.
+	Agent string
+
+	// MIBs are the names of the MIBs loaded for the target.
+	MIBs []string
+
+	// State is the registration state of the target.
+	State string
+
+	// Attempts is the number of discovery attempts made for the target.
+	Attempts int
+
+	// Supported is the number of OIDs the agent was found to support. It
+	// is zero until discovery succeeds.
+	Supported int
+
+	// LastAttempt is the time of the most recent discovery attempt.
+	LastAttempt time.Time
+
+	// LastError is the error from the most recent failed discovery attempt.
+	// It is cleared when discovery succeeds.
+	LastError string
+
+	// NextAttempt is the time at which discovery will next be attempted for
+	// a pending target.
+	NextAttempt time.Time
+
+	// Cached indicates that the supported OIDs were restored from the discovery
+	// cache and have not yet been refreshed from the agent.
+	Cached bool
+
+	// Missing are the OIDs of required devices which the agent does not support.
+	// They are only set for degraded targets.
+	Missing []string
+
+	// Placeholders are the OIDs of the target's registered devices which the
+	// agent has not yet been found to support, e.g. every device registered
+	// while registration is deferred. They do not report readings.
+	Placeholders []string
+
+	// Retired are the OIDs of the target's registered devices which the agent
+	// supported, but no longer supports since it was rediscovered. Reading them
+	// fails with ErrRetired.
+	Retired []string
+
+	// RestartRequired is set, to the reason why, when the target's devices
+	// need to be registered again for them to match the agent, which requires
+	// the plugin to be restarted.
+	RestartRequired string
+
+	// Identity is the identity of the target's agent used in the IDs of its
+	// devices (see SnmpIdentityConfiguration). It is empty until it is resolved.
+	Identity string
+
+	// Health is the health of the target's agent, as observed from the requests
+	// made to it.
+	Health HealthStatus
+}
+
+// Target holds the runtime state for a configured SNMP target. It is shared
+// between device registration, background discovery, and the device handlers
+// of the target's devices. It is safe for concurrent use.
+type Target struct {
+	Config *SnmpTargetConfiguration
+
+	mu         sync.RWMutex
+	status     TargetStatus
+	supported  map[string]struct{}
+	retired    map[string]struct{}
+	registered []string
+
+	health   *AgentHealth
+	restarts chan struct{}
+
+	seeds  DiscoveredValues
+	seeded time.Time
+
+	decoders map[string]string
+}
+
+// NewTarget creates the runtime state for an SNMP target which loads devices
+// from the named MIBs.
+func NewTarget(cfg *SnmpTargetConfiguration, mibs []string) *Target {
+	health := NewAgentHealth(cfg.Agent)
+	return &Target{
+		Config: cfg,
+		status: TargetStatus{
+			Agent: cfg.Agent,
+			MIBs:  mibs,
+			State: TargetPending,
+		},
+		health:   health,
+		restarts: health.subscribe(),
+	}
+}
+
+// NewClient creates a client for the target's agent (see NewClient). The outcome of
+// each request made with the client is recorded in the health of the target's agent.
+func (t *Target) NewClient() (*Client, error) {
+	c, err := NewClient(t.Config)
+	if err != nil {
+		return nil, err
+	}
+	c.health = t.health
+	return c, nil
+}
+
+// Health gets the health of the target's agent, as observed from the requests made
+// by the target's clients (see NewClient).
+func (t *Target) Health() *AgentHealth {
+	return t.health
+}
+
+// Status gets a snapshot of the target's registration status.
+func (t *Target) Status() TargetStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	status := t.status
+	status.MIBs = append([]string(nil), t.status.MIBs...)
+	status.Missing = append([]string(nil), t.status.Missing...)
+	for _, oid := range t.registered {
+		if _, supported := t.supported[oid]; supported {
+			continue
+		}
+		if _, retired := t.retired[oid]; retired {
+			status.Retired = append(status.Retired, oid)
+		} else {
+			status.Placeholders = append(status.Placeholders, oid)
+		}
+	}
+	status.Health = t.health.Status()
+	return status
+}
+
+// SetRegistered sets the OIDs of the devices registered for the target, so that
+// those which the agent is not known to support are reported as placeholders (see
+// TargetStatus.Placeholders).
+func (t *Target) SetRegistered(oids []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.registered = oids
+}
+
+// IsRegistered checks whether a device is registered for the given OID (see
+// SetRegistered).
+func (t *Target) IsRegistered(oid string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, registered := range t.registered {
+		if registered == oid {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIdentity sets the identity of the target's agent.
+func (t *Target) SetIdentity(identity string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Identity = identity
+}
+
+// Identity gets the identity of the target's agent. It is empty until it is set.
+func (t *Target) Identity() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.status.Identity
+}
+
+// SetMIBs updates the names of the MIBs loaded for the target.
+func (t *Target) SetMIBs(mibs []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.MIBs = mibs
+}
+
+// Discovered records a successful discovery attempt and the set of OIDs the
+// agent supports. OIDs which the agent previously supported, but which are not
+// in the set, are retired (see IsRetired).
+func (t *Target) Discovered(supported map[string]struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	retired := map[string]struct{}{}
+	for oid := range t.retired {
+		if _, ok := supported[oid]; !ok {
+			retired[oid] = struct{}{}
+		}
+	}
+	for oid := range t.supported {
+		if _, ok := supported[oid]; !ok {
+			retired[oid] = struct{}{}
+		}
+	}
+	t.retired = retired
+	t.supported = supported
+	t.status.State = TargetRegistered
+	t.status.Attempts++
+	t.status.Supported = len(supported)
+	t.status.LastAttempt = time.Now()
+	t.status.LastError = ""
+	t.status.NextAttempt = time.Time{}
+	t.status.Cached = false
+	t.status.Missing = nil
+}
+
+// Degraded marks a registered target as degraded, as its agent does not support
+// the required devices with the given OIDs.
+func (t *Target) Degraded(missing []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.State = TargetDegraded
+	t.status.Missing = missing
+}
+
+// Restored records the set of OIDs the agent supports as restored from the
+// discovery cache. The target is registered, but the OIDs should be refreshed
+// from the agent with a discovery attempt.
+func (t *Target) Restored(supported map[string]struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.supported = supported
+	t.status.State = TargetRegistered
+	t.status.Supported = len(supported)
+	t.status.Cached = true
+}
+
+// DiscoveryFailed records a failed discovery attempt. If the attempt will be
+// retried, next is the time of the next attempt; otherwise, it should be the
+// zero time and the target is marked as failed.
+//
+// If discovery previously succeeded for the target (i.e. a rediscovery attempt
+// failed), the target remains registered with its previously supported OIDs.
+func (t *Target) DiscoveryFailed(err error, next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Attempts++
+	t.status.LastAttempt = time.Now()
+	t.status.LastError = err.Error()
+	t.status.NextAttempt = next
+	if t.supported != nil {
+		return
+	}
+	if next.IsZero() {
+		t.status.State = TargetFailed
+	} else {
+		t.status.State = TargetPending
+	}
+}
+
+// SetDecoders sets the decoders used to read the values of OIDs whose type differs
+// from the type their device expects, keyed by OID.
+func (t *Target) SetDecoders(decoders map[string]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.decoders = decoders
+}
+
+// Decoder gets the decoder used to read the value of the given OID. If the OID's
+// value is read as-is, an empty string is returned.
+func (t *Target) Decoder(oid string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.decoders[oid]
+}
+
+// IsSupported checks whether the agent supports the given OID. If discovery has
+// not yet succeeded for the target, it is not known whether the OID is supported,
+// so false is returned.
+func (t *Target) IsSupported(oid string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	_, supported := t.supported[oid]
+	return supported
+}
+
+// Supported gets the set of OIDs the agent supports. It is empty until discovery
+// succeeds for the target.
+func (t *Target) Supported() map[string]struct{} {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	supported := make(map[string]struct{}, len(t.supported))
+	for oid := range t.supported {
+		supported[oid] = struct{}{}
+	}
+	return supported
+}
+
+// IsRetired checks whether the agent supported the given OID, but no longer
+// supports it since it was rediscovered.
+func (t *Target) IsRetired(oid string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	_, retired := t.retired[oid]
+	return retired
+}
+
+// RequireRestart records that the target's devices need to be registered again,
+// which requires the plugin to be restarted, for the given reason.
+func (t *Target) RequireRestart(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.RestartRequired = reason
+}
+
+// ObserveUptime records the sysUpTime of the target's agent (see
+// AgentHealth.ObserveUptime). If the agent has restarted, a restart notification is
+// sent (see Restarts) and true is returned.
+//
+// Clients record the sysUpTime of every response which holds it, so this only
+// needs to be called for uptimes which were not got with a client.
+func (t *Target) ObserveUptime(ticks uint32) bool {
+	return t.health.ObserveUptime(ticks)
+}
+
+// NotifyRestart sends a notification that the target's agent has restarted. If a
+// notification is already pending, this does nothing.
+func (t *Target) NotifyRestart() {
+	select {
+	case t.restarts <- struct{}{}:
+	default:
+	}
+}
+
+// Restarts gets the channel on which agent restart notifications are sent. Restarts
+// detected from any request made by the target's clients are notified (see
+// AgentHealth.Subscribe).
+func (t *Target) Restarts() <-chan struct{} {
+	return t.restarts
+}
+
+// Seed stores the values the agent returned during discovery, so they can be used
+// for the first reading of each device rather than getting the OID from the agent
+// again. Any previously seeded values are replaced.
+func (t *Target) Seed(values DiscoveredValues) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seeds = values
+	t.seeded = time.Now()
+}
+
+// TakeSeed gets the value seeded for the given OID and removes it, so each seeded
+// value is only used once. Values which are too old to be used as a reading are
+// not returned.
+func (t *Target) TakeSeed(oid string) (gosnmp.SnmpPDU, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	value, ok := t.seeds[oid]
+	if !ok {
+		return gosnmp.SnmpPDU{}, false
+	}
+	delete(t.seeds, oid)
+	if time.Since(t.seeded) > seedMaxAge {
+		t.seeds = nil
+		return gosnmp.SnmpPDU{}, false
+	}
+	return value, true
+}
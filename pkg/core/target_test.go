@@ -0,0 +1,224 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTarget(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+
+	status := target.Status()
+	assert.Equal(t, "localhost", status.Agent)
+	assert.Equal(t, []string{"test-mib"}, status.MIBs)
+	assert.Equal(t, TargetPending, status.State)
+	assert.Equal(t, 0, status.Attempts)
+	assert.False(t, target.IsSupported("1.2.3.4"))
+}
+
+func TestTarget_Discovered(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{
+		"1.2.3.4": {},
+		"1.2.3.5": {},
+	})
+
+	status := target.Status()
+	assert.Equal(t, TargetRegistered, status.State)
+	assert.Equal(t, 1, status.Attempts)
+	assert.Equal(t, 2, status.Supported)
+	assert.Equal(t, "", status.LastError)
+	assert.False(t, status.LastAttempt.IsZero())
+	assert.True(t, target.IsSupported("1.2.3.4"))
+	assert.False(t, target.IsSupported("1.2.3.6"))
+}
+
+func TestTarget_SetRegistered(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+	target.SetRegistered([]string{"1.2.3.4", "1.2.3.5"})
+	assert.True(t, target.IsRegistered("1.2.3.4"))
+	assert.False(t, target.IsRegistered("1.2.3.6"))
+
+	// Until discovery succeeds, every registered device is a placeholder.
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.5"}, target.Status().Placeholders)
+
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}})
+	assert.Equal(t, []string{"1.2.3.5"}, target.Status().Placeholders)
+}
+
+func TestTarget_Discovered_Retired(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+	target.SetRegistered([]string{"1.2.3.4", "1.2.3.5", "1.2.3.6"})
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}, "1.2.3.5": {}})
+	assert.False(t, target.IsRetired("1.2.3.5"))
+
+	// OIDs which are no longer supported on rediscovery are retired, rather
+	// than being placeholders, until the agent supports them again.
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}})
+	assert.True(t, target.IsRetired("1.2.3.5"))
+	status := target.Status()
+	assert.Equal(t, []string{"1.2.3.5"}, status.Retired)
+	assert.Equal(t, []string{"1.2.3.6"}, status.Placeholders)
+
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}})
+	assert.True(t, target.IsRetired("1.2.3.5"))
+
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}, "1.2.3.5": {}})
+	assert.False(t, target.IsRetired("1.2.3.5"))
+	assert.Empty(t, target.Status().Retired)
+}
+
+func TestTarget_RequireRestart(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+	assert.Equal(t, "", target.Status().RestartRequired)
+
+	target.RequireRestart("detected MIBs changed")
+	assert.Equal(t, "detected MIBs changed", target.Status().RestartRequired)
+}
+
+func TestTarget_DiscoveryFailed(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+
+	next := time.Now().Add(time.Minute)
+	target.DiscoveryFailed(errors.New("timeout"), next)
+
+	status := target.Status()
+	assert.Equal(t, TargetPending, status.State)
+	assert.Equal(t, 1, status.Attempts)
+	assert.Equal(t, "timeout", status.LastError)
+	assert.Equal(t, next, status.NextAttempt)
+
+	target.DiscoveryFailed(errors.New("timeout"), time.Time{})
+
+	status = target.Status()
+	assert.Equal(t, TargetFailed, status.State)
+	assert.Equal(t, 2, status.Attempts)
+	assert.True(t, status.NextAttempt.IsZero())
+}
+
+func TestTarget_DiscoveryFailed_AfterDiscovered(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{
+		"1.2.3.4": {},
+	})
+	target.DiscoveryFailed(errors.New("timeout"), time.Time{})
+
+	status := target.Status()
+	assert.Equal(t, TargetRegistered, status.State)
+	assert.Equal(t, 2, status.Attempts)
+	assert.Equal(t, "timeout", status.LastError)
+	assert.True(t, target.IsSupported("1.2.3.4"))
+}
+
+func TestTarget_Restored(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+	target.Restored(map[string]struct{}{
+		"1.2.3.4": {},
+	})
+
+	status := target.Status()
+	assert.Equal(t, TargetRegistered, status.State)
+	assert.Equal(t, 0, status.Attempts)
+	assert.Equal(t, 1, status.Supported)
+	assert.True(t, status.Cached)
+	assert.True(t, target.IsSupported("1.2.3.4"))
+
+	target.Discovered(map[string]struct{}{})
+	assert.False(t, target.Status().Cached)
+	assert.False(t, target.IsSupported("1.2.3.4"))
+}
+
+func TestTarget_ObserveUptime(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, nil)
+
+	assert.False(t, target.ObserveUptime(100))
+	assert.False(t, target.ObserveUptime(200))
+	assert.True(t, target.ObserveUptime(10))
+
+	select {
+	case <-target.Restarts():
+	default:
+		t.Fatal("expected restart notification")
+	}
+}
+
+func TestTarget_NotifyRestart(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, nil)
+
+	// Multiple notifications collapse into a single pending notification.
+	target.NotifyRestart()
+	target.NotifyRestart()
+
+	<-target.Restarts()
+	select {
+	case <-target.Restarts():
+		t.Fatal("unexpected restart notification")
+	default:
+	}
+}
+
+func TestTarget_SetMIBs(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, nil)
+	target.SetMIBs([]string{"mib-1", "mib-2"})
+
+	assert.Equal(t, []string{"mib-1", "mib-2"}, target.Status().MIBs)
+}
+
+func TestTarget_Seed(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, nil)
+	target.Seed(DiscoveredValues{
+		"1.2.3.4": {Name: ".1.2.3.4", Type: gosnmp.Integer, Value: 20},
+	})
+
+	value, ok := target.TakeSeed("1.2.3.4")
+	assert.True(t, ok)
+	assert.Equal(t, 20, value.Value)
+
+	// Seeded values are only used once.
+	_, ok = target.TakeSeed("1.2.3.4")
+	assert.False(t, ok)
+
+	_, ok = target.TakeSeed("1.2.3.5")
+	assert.False(t, ok)
+}
+
+func TestTarget_Seed_Expired(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, nil)
+	target.Seed(DiscoveredValues{
+		"1.2.3.4": {Name: ".1.2.3.4", Type: gosnmp.Integer, Value: 20},
+	})
+	target.seeded = time.Now().Add(-2 * seedMaxAge)
+
+	_, ok := target.TakeSeed("1.2.3.4")
+	assert.False(t, ok)
+}
+
+func TestTarget_Decoder(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, nil)
+	assert.Equal(t, "", target.Decoder("1.2.3.4"))
+
+	target.SetDecoders(map[string]string{"1.2.3.4": DecoderNumericString})
+	assert.Equal(t, DecoderNumericString, target.Decoder("1.2.3.4"))
+	assert.Equal(t, "", target.Decoder("1.2.3.5"))
+}
+
+func TestTarget_Degraded(t *testing.T) {
+	target := NewTarget(&SnmpTargetConfiguration{Agent: "localhost"}, nil)
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}})
+	target.Degraded([]string{"1.2.3.5"})
+
+	status := target.Status()
+	assert.Equal(t, TargetDegraded, status.State)
+	assert.Equal(t, []string{"1.2.3.5"}, status.Missing)
+	assert.True(t, target.IsSupported("1.2.3.4"))
+
+	// A later discovery which finds all required devices clears the state.
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}, "1.2.3.5": {}})
+	status = target.Status()
+	assert.Equal(t, TargetRegistered, status.State)
+	assert.Empty(t, status.Missing)
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/soniah/gosnmp"
+)
+
+// SMI types which the OID of an SNMP device may be declared as.
+const (
+	SMIInteger32        = "Integer32"
+	SMIUnsigned32       = "Unsigned32"
+	SMIGauge32          = "Gauge32"
+	SMICounter32        = "Counter32"
+	SMICounter64        = "Counter64"
+	SMITimeTicks        = "TimeTicks"
+	SMIOctetString      = "OctetString"
+	SMIObjectIdentifier = "ObjectIdentifier"
+	SMIIpAddress        = "IpAddress"
+	SMIOpaque           = "Opaque"
+)
+
+// smiTypes maps the PDU types which values are returned as to their SMI type.
+var smiTypes = map[gosnmp.Asn1BER]string{
+	gosnmp.Integer:          SMIInteger32,
+	gosnmp.Uinteger32:       SMIUnsigned32,
+	gosnmp.Gauge32:          SMIGauge32,
+	gosnmp.Counter32:        SMICounter32,
+	gosnmp.Counter64:        SMICounter64,
+	gosnmp.TimeTicks:        SMITimeTicks,
+	gosnmp.OctetString:      SMIOctetString,
+	gosnmp.ObjectIdentifier: SMIObjectIdentifier,
+	gosnmp.IPAddress:        SMIIpAddress,
+	gosnmp.Opaque:           SMIOpaque,
+	gosnmp.OpaqueFloat:      SMIOpaque,
+	gosnmp.OpaqueDouble:     SMIOpaque,
+}
+
+// SMIType gets the SMI type of a value returned by an agent as the given PDU type.
+// If the PDU type does not hold a value (e.g. an exception), an empty string is
+// returned.
+func SMIType(t gosnmp.Asn1BER) string {
+	return smiTypes[t]
+}
+
+// HasSMIType checks whether a value returned by an agent as the given PDU type is of
+// the given SMI type. Unsigned32 and Gauge32 share the same tag on the wire (RFC
+// 2578), so agents send Unsigned32 values as Gauge32: either type matches both.
+func HasSMIType(t gosnmp.Asn1BER, name string) bool {
+	actual := SMIType(t)
+	if actual == SMIGauge32 || actual == SMIUnsigned32 {
+		return name == SMIGauge32 || name == SMIUnsigned32
+	}
+	return actual != "" && actual == name
+}
+
+// IsSMIType checks whether the given name is one of the supported SMI types.
+func IsSMIType(name string) bool {
+	for _, smiType := range smiTypes {
+		if smiType == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNumericSMIType checks whether values of the given SMI type are numeric.
+func IsNumericSMIType(name string) bool {
+	switch name {
+	case SMIInteger32, SMIUnsigned32, SMIGauge32, SMICounter32, SMICounter64, SMITimeTicks:
+		return true
+	}
+	return false
+}
+
+// IsNumeric checks whether values returned as the given PDU type are numeric.
+func IsNumeric(t gosnmp.Asn1BER) bool {
+	return IsNumericSMIType(SMIType(t)) || t == gosnmp.OpaqueFloat || t == gosnmp.OpaqueDouble
+}
+
+// Decoders for values whose type differs from the type their device expects.
+const (
+	// DecoderNumericString decodes an OctetString which holds a number, e.g.
+	// "23.5", as a number.
+	DecoderNumericString = "numeric-string"
+
+	// DecoderString decodes a value of any type as a string.
+	DecoderString = "string"
+)
+
+// DecodeValue gets the reading value for an SNMP variable using the named decoder.
+// If no decoder is given, OctetStrings are converted to ASCII and any other value
+// is used as-is.
+func DecodeValue(decoder string, pdu gosnmp.SnmpPDU) (interface{}, error) {
+	switch decoder {
+	case "":
+		if pdu.Type == gosnmp.OctetString {
+			return BytesIfaceToASCII(pdu.Value)
+		}
+		return pdu.Value, nil
+
+	case DecoderNumericString:
+		s, err := BytesIfaceToASCII(pdu.Value)
+		if err != nil {
+			return nil, err
+		}
+		s = strings.TrimSpace(s)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %q as a number", s)
+		}
+		return f, nil
+
+	case DecoderString:
+		if pdu.Type == gosnmp.OctetString {
+			return BytesIfaceToASCII(pdu.Value)
+		}
+		return fmt.Sprint(pdu.Value), nil
+	}
+	return nil, fmt.Errorf("unknown decoder: %s", decoder)
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMIType(t *testing.T) {
+	assert.Equal(t, SMIGauge32, SMIType(gosnmp.Gauge32))
+	assert.Equal(t, SMIOctetString, SMIType(gosnmp.OctetString))
+	assert.Equal(t, "", SMIType(gosnmp.NoSuchObject))
+}
+
+func TestHasSMIType(t *testing.T) {
+	assert.True(t, HasSMIType(gosnmp.Integer, SMIInteger32))
+	assert.True(t, HasSMIType(gosnmp.Gauge32, SMIGauge32))
+	assert.True(t, HasSMIType(gosnmp.Gauge32, SMIUnsigned32))
+	assert.True(t, HasSMIType(gosnmp.Uinteger32, SMIUnsigned32))
+	assert.True(t, HasSMIType(gosnmp.Uinteger32, SMIGauge32))
+	assert.False(t, HasSMIType(gosnmp.Counter32, SMIUnsigned32))
+	assert.False(t, HasSMIType(gosnmp.Integer, SMIGauge32))
+	assert.False(t, HasSMIType(gosnmp.NoSuchObject, ""))
+}
+
+func TestIsSMIType(t *testing.T) {
+	assert.True(t, IsSMIType(SMICounter64))
+	assert.False(t, IsSMIType("Float"))
+	assert.False(t, IsSMIType(""))
+}
+
+func TestIsNumeric(t *testing.T) {
+	assert.True(t, IsNumeric(gosnmp.Integer))
+	assert.True(t, IsNumeric(gosnmp.TimeTicks))
+	assert.True(t, IsNumeric(gosnmp.OpaqueFloat))
+	assert.False(t, IsNumeric(gosnmp.OctetString))
+	assert.False(t, IsNumeric(gosnmp.IPAddress))
+}
+
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		decoder  string
+		pdu      gosnmp.SnmpPDU
+		expected interface{}
+	}{
+		{"default integer", "", gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: 20}, 20},
+		{"default string", "", gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("foo")}, "foo"},
+		{"numeric string int", DecoderNumericString, gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte(" 42 ")}, int64(42)},
+		{"numeric string float", DecoderNumericString, gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("23.5")}, 23.5},
+		{"string from integer", DecoderString, gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: 20}, "20"},
+		{"string from string", DecoderString, gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("foo")}, "foo"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := DecodeValue(test.decoder, test.pdu)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
+
+func TestDecodeValue_Error(t *testing.T) {
+	_, err := DecodeValue(DecoderNumericString, gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("n/a")})
+	assert.Error(t, err)
+
+	_, err = DecodeValue("hex", gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: 20})
+	assert.EqualError(t, err, "unknown decoder: hex")
+}
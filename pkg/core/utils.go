@@ -3,6 +3,7 @@ package core
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -123,3 +124,52 @@ func BytesIfaceToASCII(b interface{}) (string, error) {
 	}
 	return string(bytes), nil
 }
+
+// NormalizeOid strips the leading dot, if any, from an OID string. OIDs returned
+// by the SNMP agent are generally prefixed with a dot, whereas OIDs defined by
+// MIBs registered with the plugin base are not.
+func NormalizeOid(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
+
+// OidHasPrefix checks whether the given OID falls within the subtree rooted at
+// the given prefix OID. The match is done on whole OID components, so
+// "1.3.6.1.4.1.5345" is not considered to be within "1.3.6.1.4.1.534".
+func OidHasPrefix(oid, prefix string) bool {
+	oid = NormalizeOid(oid)
+	prefix = NormalizeOid(prefix)
+	if prefix == "" {
+		return true
+	}
+	return oid == prefix || strings.HasPrefix(oid, prefix+".")
+}
+
+// CompareOids compares two OIDs in lexicographic order of their numeric components,
+// which is the order in which an SNMP agent walks its MIB view. It returns -1 if a
+// comes before b, 1 if a comes after b, and 0 if they are the same OID. Components
+// which are not numeric are compared as strings.
+func CompareOids(a, b string) int {
+	as := strings.Split(NormalizeOid(a), ".")
+	bs := strings.Split(NormalizeOid(b), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		if aErr != nil || bErr != nil {
+			return strings.Compare(as[i], bs[i])
+		}
+		if an < bn {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
@@ -275,3 +275,83 @@ func TestBytesIfaceToASCII_UnableToConvert(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "", str)
 }
+
+func TestNormalizeOid(t *testing.T) {
+	assert.Equal(t, "1.2.3", NormalizeOid(".1.2.3"))
+	assert.Equal(t, "1.2.3", NormalizeOid("1.2.3"))
+	assert.Equal(t, "", NormalizeOid(""))
+}
+
+func TestOidHasPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		oid      string
+		prefix   string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			oid:      "1.3.6.1.4.1.534",
+			prefix:   "1.3.6.1.4.1.534",
+			expected: true,
+		},
+		{
+			name:     "within subtree",
+			oid:      "1.3.6.1.4.1.534.1.2",
+			prefix:   "1.3.6.1.4.1.534",
+			expected: true,
+		},
+		{
+			name:     "within subtree, leading dots",
+			oid:      ".1.3.6.1.4.1.534.1.2",
+			prefix:   ".1.3.6.1.4.1.534",
+			expected: true,
+		},
+		{
+			name:     "partial component",
+			oid:      "1.3.6.1.4.1.5345.1",
+			prefix:   "1.3.6.1.4.1.534",
+			expected: false,
+		},
+		{
+			name:     "different subtree",
+			oid:      "1.3.6.1.2.1.33",
+			prefix:   "1.3.6.1.4.1.534",
+			expected: false,
+		},
+		{
+			name:     "empty prefix",
+			oid:      "1.3.6.1.2.1.33",
+			prefix:   "",
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, OidHasPrefix(test.oid, test.prefix))
+		})
+	}
+}
+
+func TestCompareOids(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{".1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.2.4", "1.2.3", 1},
+		{"1.2.3", "1.2.3.1", -1},
+		{"1.2.3.1", "1.2.3", 1},
+		{"1.2.9", "1.2.10", -1},
+		{"1.2.10", "1.2.9", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			assert.Equal(t, test.expected, CompareOids(test.a, test.b))
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/soniah/gosnmp"
+)
+
+// Modes for walking the subtrees of an SNMP agent.
+const (
+	// WalkAuto walks with GETBULK, falling back to GETNEXT if the agent does
+	// not handle GETBULK properly. SNMPv1 agents, which do not support GETBULK,
+	// are always walked with GETNEXT.
+	WalkAuto = "auto"
+
+	// WalkBulk walks with GETBULK only.
+	WalkBulk = "bulk"
+
+	// WalkGetNext walks with GETNEXT only.
+	WalkGetNext = "getnext"
+)
+
+// defaultMaxRepetitions is the GETBULK max-repetitions used when walking, unless
+// the client specifies otherwise.
+const defaultMaxRepetitions = 50
+
+// Errors for walking the subtrees of an SNMP agent.
+var (
+	ErrNonIncreasingOid = errors.New("agent returned a non-increasing OID")
+	ErrMaxOidsExceeded  = errors.New("walk exceeded the maximum number of OIDs")
+	ErrEmptyBulkResult  = errors.New("agent returned an empty GETBULK response")
+
+	ErrWalkDurationExceeded = errors.New("walk exceeded the maximum duration")
+)
+
+// PartialWalkError is returned along with the results of a walk which ended before
+// the entire subtree was walked, e.g. because the agent stopped responding or a walk
+// limit was reached. The results which were received are still valid.
+type PartialWalkError struct {
+	// RootOid is the root OID of the walk.
+	RootOid string
+
+	// LastOid is the last OID which was received before the walk ended.
+	LastOid string
+
+	// Err is the reason the walk ended early.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *PartialWalkError) Error() string {
+	return fmt.Sprintf("walk of %s ended early after %s: %v", e.RootOid, e.LastOid, e.Err)
+}
+
+// Unwrap returns the reason the walk ended early.
+func (e *PartialWalkError) Unwrap() error {
+	return e.Err
+}
+
+// Unwalked checks whether the given OID is in the part of the walked subtree which
+// was not reached before the walk ended. Whether the agent supports such an OID is
+// not known.
+func (e *PartialWalkError) Unwalked(oid string) bool {
+	return OidHasPrefix(oid, e.RootOid) && CompareOids(NormalizeOid(oid), e.LastOid) > 0
+}
+
+// PartialDiscoveryError is returned along with the values discovered by walking the
+// subtrees of an agent when one or more of the walks ended early (see
+// GetSupportedValues). The values which were discovered are still valid, but the
+// agent may support OIDs which were not reached by the walks (see Unwalked).
+type PartialDiscoveryError struct {
+	// Walks are the walks which ended early.
+	Walks []*PartialWalkError
+}
+
+// Error implements the error interface.
+func (e *PartialDiscoveryError) Error() string {
+	walks := make([]string, len(e.Walks))
+	for i, w := range e.Walks {
+		walks[i] = w.Error()
+	}
+	return fmt.Sprintf("discovery incomplete: %s", strings.Join(walks, "; "))
+}
+
+// Unwalked checks whether the given OID was not reached by a walk which ended early.
+func (e *PartialDiscoveryError) Unwalked(oid string) bool {
+	for _, w := range e.Walks {
+		if w.Unwalked(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkSubtree gets all of the variables in the subtree under the given root OID.
+//
+// How the subtree is walked depends on the client's walk mode (see WalkAuto). The
+// walk fails if the agent returns an OID which does not come after the previously
+// returned OID, as agents which do so would otherwise be walked forever.
+//
+// Variables in the configured excluded subtrees are skipped. If a request fails,
+// including the first request of the walk, the walk resumes from the last received
+// OID (or the root OID), up to the configured number of times in total. If no results
+// were received by then, the request's error is returned. If the walk still cannot
+// be completed after receiving some results, or it reaches
+// the configured maximum duration or number of OIDs, the results received so far
+// are returned along with a PartialWalkError.
+func (c *Client) WalkSubtree(rootOid string) ([]gosnmp.SnmpPDU, error) {
+	switch c.walkMode() {
+	case WalkGetNext:
+		return c.walk(rootOid, false)
+	case WalkBulk:
+		return c.walk(rootOid, true)
+	}
+
+	results, err := c.walk(rootOid, true)
+	if err == nil || (c.Context != nil && c.Context.Err() != nil) {
+		return results, err
+	}
+	if _, partial := err.(*PartialWalkError); partial {
+		return results, err
+	}
+
+	// The agent does not handle GETBULK properly, so walk with GETNEXT instead.
+	// This is remembered, so any subsequent walks with the client skip GETBULK.
+	log.WithError(err).WithFields(log.Fields{
+		"rootOid": rootOid,
+		"agent":   c.Target,
+	}).Warn("[snmp] GETBULK walk failed; falling back to GETNEXT")
+	c.bulkFailed = true
+	return c.walk(rootOid, false)
+}
+
+// walkMode gets the mode the next walk with the client should use.
+func (c *Client) walkMode() string {
+	switch c.walkConfig.Mode {
+	case WalkBulk, WalkGetNext:
+		return c.walkConfig.Mode
+	}
+	if c.Version == gosnmp.Version1 || c.bulkFailed {
+		return WalkGetNext
+	}
+	return WalkAuto
+}
+
+// walk walks the subtree under the given root OID with either GETBULK or GETNEXT
+// requests.
+func (c *Client) walk(rootOid string, bulk bool) ([]gosnmp.SnmpPDU, error) {
+	rootOid = NormalizeOid(rootOid)
+	cfg := c.walkConfig
+	maxReps := c.MaxRepetitions
+	if maxReps == 0 {
+		maxReps = defaultMaxRepetitions
+	}
+	if excluded := c.excludedSubtree(rootOid); excluded != "" {
+		log.WithFields(log.Fields{
+			"rootOid":  rootOid,
+			"excluded": excluded,
+		}).Debug("[snmp] not walking excluded subtree")
+		return nil, nil
+	}
+
+	start := time.Now()
+	partial := func(results []gosnmp.SnmpPDU, lastOid string, err error) ([]gosnmp.SnmpPDU, error) {
+		log.WithError(err).WithFields(log.Fields{
+			"rootOid": rootOid,
+			"lastOid": lastOid,
+			"results": len(results),
+		}).Warn("[snmp] walk ended early; returning partial results")
+		return results, &PartialWalkError{RootOid: rootOid, LastOid: lastOid, Err: err}
+	}
+
+	var results []gosnmp.SnmpPDU
+	var received, resumes int
+	oid := rootOid
+	for {
+		if cfg.MaxDuration > 0 && time.Since(start) > cfg.MaxDuration {
+			return partial(results, oid, ErrWalkDurationExceeded)
+		}
+
+		var response *gosnmp.SnmpPacket
+		var err error
+		if bulk {
+			response, err = c.GetBulk([]string{oid}, uint8(c.NonRepeaters), maxReps)
+		} else {
+			response, err = c.GetNext([]string{oid})
+		}
+		if err != nil {
+			// Failed requests are retried from the last received OID, or the
+			// root OID if nothing was received yet, within the same budget.
+			if resumes < cfg.Resumes && (c.Context == nil || c.Context.Err() == nil) {
+				resumes++
+				log.WithError(err).WithFields(log.Fields{
+					"rootOid": rootOid,
+					"lastOid": oid,
+					"attempt": resumes,
+				}).Warn("[snmp] walk request failed; resuming from last received OID")
+				continue
+			}
+			// Errors before any results are received mean that the agent
+			// cannot be walked at all.
+			if len(results) == 0 {
+				return nil, err
+			}
+			return partial(results, oid, err)
+		}
+
+		if response.Error != gosnmp.NoError {
+			// SNMPv1 agents respond with noSuchName once the end of the MIB
+			// view is reached.
+			if !bulk && response.Error == gosnmp.NoSuchName {
+				return results, nil
+			}
+			return nil, fmt.Errorf("walk of %s failed at %s: %s", rootOid, oid, response.Error)
+		}
+		if len(response.Variables) == 0 {
+			if bulk {
+				return nil, ErrEmptyBulkResult
+			}
+			return results, nil
+		}
+
+		for _, v := range response.Variables {
+			name := NormalizeOid(v.Name)
+			switch v.Type {
+			case gosnmp.EndOfMibView, gosnmp.NoSuchObject, gosnmp.NoSuchInstance:
+				return results, nil
+			}
+			if !OidHasPrefix(name, rootOid) {
+				return results, nil
+			}
+
+			// OIDs in excluded subtrees count towards the limit, as the
+			// agent still has to return them.
+			if cfg.MaxOids > 0 && received >= cfg.MaxOids {
+				return partial(results, oid, ErrMaxOidsExceeded)
+			}
+			received++
+
+			// Skip past excluded subtrees. The walk continues from the largest
+			// possible OID within the subtree, so the next OID the agent
+			// returns is the first one after it.
+			if excluded := c.excludedSubtree(name); excluded != "" {
+				skip := excluded + ".4294967295"
+				if CompareOids(skip, oid) > 0 {
+					oid = skip
+				}
+				continue
+			}
+
+			if CompareOids(name, oid) <= 0 {
+				log.WithFields(log.Fields{
+					"rootOid":  rootOid,
+					"previous": oid,
+					"returned": name,
+				}).Error("[snmp] agent returned a non-increasing OID during walk")
+				return nil, ErrNonIncreasingOid
+			}
+
+			results = append(results, v)
+			oid = name
+		}
+	}
+}
+
+// excludedSubtree gets the excluded subtree which the given OID falls within. If the
+// OID is not excluded, an empty string is returned.
+func (c *Client) excludedSubtree(oid string) string {
+	for _, excluded := range c.walkConfig.Exclude {
+		if OidHasPrefix(oid, excluded) {
+			return NormalizeOid(excluded)
+		}
+	}
+	return ""
+}
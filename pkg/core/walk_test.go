@@ -0,0 +1,351 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+)
+
+// testVars are the variables served by test agents: a small subtree, along with a
+// variable outside of that subtree.
+var testVars = []gosnmp.SnmpPDU{
+	{Name: "1.2.3.1.0", Type: gosnmp.Integer, Value: 1},
+	{Name: "1.2.3.2.1", Type: gosnmp.Integer, Value: 2},
+	{Name: "1.2.3.2.2", Type: gosnmp.Integer, Value: 3},
+	{Name: "1.2.3.10.0", Type: gosnmp.OctetString, Value: []byte("foo")},
+	{Name: "1.2.4.1.0", Type: gosnmp.Integer, Value: 4},
+}
+
+// newTestClient creates a connected client for a test agent.
+func newTestClient(t *testing.T, agent *snmptest.Agent, version string, walk SnmpWalkConfiguration) *Client {
+	client, err := NewClient(&SnmpTargetConfiguration{
+		Version:   version,
+		Agent:     agent.Addr(),
+		Community: "public",
+		Timeout:   200 * time.Millisecond,
+		Retries:   1,
+		Walk:      walk,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// walkedOids gets the normalized OIDs of walk results.
+func walkedOids(results []gosnmp.SnmpPDU) []string {
+	var oids []string
+	for _, r := range results {
+		oids = append(oids, NormalizeOid(r.Name))
+	}
+	return oids
+}
+
+func TestClient_WalkSubtree_Bulk(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.2.1", "1.2.3.2.2", "1.2.3.10.0"}, walkedOids(results))
+	assert.Equal(t, 0, agent.Requests(gosnmp.GetNextRequest))
+	assert.NotZero(t, agent.Requests(gosnmp.GetBulkRequest))
+}
+
+func TestClient_WalkSubtree_V1(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version1, testVars...)
+	defer agent.Close()
+
+	client := newTestClient(t, agent, "v1", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	// The walk ends at the end of the MIB view, which a v1 agent reports with
+	// a noSuchName error.
+	results, err := client.WalkSubtree("1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.2.1", "1.2.3.2.2", "1.2.3.10.0", "1.2.4.1.0"}, walkedOids(results))
+	assert.Equal(t, 0, agent.Requests(gosnmp.GetBulkRequest))
+}
+
+func TestClient_WalkSubtree_BulkFallback(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		if request.PDUType == gosnmp.GetBulkRequest {
+			return &gosnmp.SnmpPacket{Error: gosnmp.GenErr}, false
+		}
+		return nil, false
+	})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.2.1", "1.2.3.2.2", "1.2.3.10.0"}, walkedOids(results))
+	bulkRequests := agent.Requests(gosnmp.GetBulkRequest)
+	assert.NotZero(t, bulkRequests)
+
+	// Subsequent walks do not attempt GETBULK again.
+	_, err = client.WalkSubtree("1.2.4")
+	assert.NoError(t, err)
+	assert.Equal(t, bulkRequests, agent.Requests(gosnmp.GetBulkRequest))
+}
+
+func TestClient_WalkSubtree_BulkOnly(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		if request.PDUType == gosnmp.GetBulkRequest {
+			return &gosnmp.SnmpPacket{Error: gosnmp.GenErr}, false
+		}
+		return nil, false
+	})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{Mode: WalkBulk})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.Equal(t, 0, agent.Requests(gosnmp.GetNextRequest))
+}
+
+func TestClient_WalkSubtree_NonIncreasing(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	// The agent returns the same OID for every request, so a walk would never
+	// terminate without loop detection.
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		return &gosnmp.SnmpPacket{
+			Variables: []gosnmp.SnmpPDU{
+				{Name: ".1.2.3.1.0", Type: gosnmp.Integer, Value: 1},
+			},
+		}, false
+	})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.Equal(t, ErrNonIncreasingOid, err)
+	assert.Nil(t, results)
+}
+
+func TestClient_WalkSubtree_MaxOids(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{Mode: WalkGetNext, MaxOids: 2})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.2.1"}, walkedOids(results))
+
+	perr, ok := err.(*PartialWalkError)
+	assert.True(t, ok)
+	assert.Equal(t, ErrMaxOidsExceeded, perr.Err)
+	assert.Equal(t, "1.2.3.2.1", perr.LastOid)
+
+	// A subtree which holds exactly the maximum number of OIDs is walked fully.
+	results, err = client.WalkSubtree("1.2.3.2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.2.1", "1.2.3.2.2"}, walkedOids(results))
+}
+
+func TestClient_GetSupportedDevices_V1(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version1, testVars...)
+	defer agent.Close()
+
+	client := newTestClient(t, agent, "v1", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	devices, err := client.GetSupportedDevices("1.2.3", "1.2.4")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"1.2.3.1.0":  {},
+		"1.2.3.2.1":  {},
+		"1.2.3.2.2":  {},
+		"1.2.3.10.0": {},
+		"1.2.4.1.0":  {},
+	}, devices)
+}
+
+func TestClient_ProbeOids_V1(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version1, testVars...)
+	defer agent.Close()
+
+	client := newTestClient(t, agent, "v1", SnmpWalkConfiguration{})
+	defer client.Close()
+
+	// A v1 agent fails the batched request since one of the OIDs does not exist,
+	// so the OIDs are probed individually.
+	devices, err := client.ProbeOids("1.2.3.1.0", "1.2.3.5.0", "1.2.4.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"1.2.3.1.0": {},
+		"1.2.4.1.0": {},
+	}, devices)
+}
+
+func TestClient_WalkSubtree_Resume(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	// The agent times out on the first request for an OID partway through the walk.
+	var dropped bool
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		if !dropped && request.Variables[0].Name == ".1.2.3.2.1" {
+			dropped = true
+			return nil, true
+		}
+		return nil, false
+	})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{Mode: WalkGetNext, Resumes: 1})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.2.1", "1.2.3.2.2", "1.2.3.10.0"}, walkedOids(results))
+}
+
+func TestClient_WalkSubtree_ResumeFirstRequest(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	// The agent times out on the first request of the walk.
+	var dropped bool
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		if !dropped && request.Variables[0].Name == ".1.2.3" {
+			dropped = true
+			return nil, true
+		}
+		return nil, false
+	})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{Mode: WalkGetNext, Resumes: 1})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.2.1", "1.2.3.2.2", "1.2.3.10.0"}, walkedOids(results))
+
+	// Without resumes left, a walk whose first request fails fails outright.
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		return nil, true
+	})
+	results, err = client.WalkSubtree("1.2.3")
+	assert.Error(t, err)
+	_, partial := err.(*PartialWalkError)
+	assert.False(t, partial)
+	assert.Nil(t, results)
+}
+
+func TestClient_WalkSubtree_PartialAfterTimeout(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	// The agent hangs on a subtree partway through the walk.
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		return nil, request.Variables[0].Name == ".1.2.3.2.1"
+	})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{Mode: WalkGetNext, Resumes: -1})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.2.1"}, walkedOids(results))
+
+	perr, ok := err.(*PartialWalkError)
+	assert.True(t, ok)
+	assert.Equal(t, "1.2.3", perr.RootOid)
+	assert.Equal(t, "1.2.3.2.1", perr.LastOid)
+
+	// The partial results are used as the supported devices.
+	devices, err := client.GetSupportedDevices("1.2.3")
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	// The values are returned along with the OIDs which were not walked.
+	values, err := client.GetSupportedValues("1.2.3", "1.2.4")
+	assert.Len(t, values, 3)
+	derr, ok := err.(*PartialDiscoveryError)
+	assert.True(t, ok)
+	assert.Len(t, derr.Walks, 1)
+	assert.False(t, derr.Unwalked("1.2.3.2.1"))
+	assert.True(t, derr.Unwalked("1.2.3.2.2"))
+	assert.True(t, derr.Unwalked("1.2.3.10.0"))
+	assert.False(t, derr.Unwalked("1.2.4.1.0"))
+}
+
+func TestClient_WalkSubtree_Exclude(t *testing.T) {
+	for _, mode := range []string{WalkBulk, WalkGetNext} {
+		t.Run(mode, func(t *testing.T) {
+			agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+			defer agent.Close()
+
+			client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{
+				Mode:    mode,
+				Exclude: []string{"1.2.3.2"},
+			})
+			defer client.Close()
+
+			results, err := client.WalkSubtree("1.2.3")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"1.2.3.1.0", "1.2.3.10.0"}, walkedOids(results))
+
+			// Excluded roots are not walked at all.
+			requests := agent.Requests(gosnmp.GetBulkRequest) + agent.Requests(gosnmp.GetNextRequest)
+			results, err = client.WalkSubtree("1.2.3.2")
+			assert.NoError(t, err)
+			assert.Empty(t, results)
+			assert.Equal(t, requests, agent.Requests(gosnmp.GetBulkRequest)+agent.Requests(gosnmp.GetNextRequest))
+		})
+	}
+}
+
+func TestClient_WalkSubtree_MaxDuration(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	// The agent is slow to respond, so the walk exceeds its maximum duration
+	// after the first response.
+	agent.Intercept(func(request *gosnmp.SnmpPacket) (*gosnmp.SnmpPacket, bool) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, false
+	})
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{Mode: WalkGetNext, MaxDuration: 10 * time.Millisecond})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.Equal(t, []string{"1.2.3.1.0"}, walkedOids(results))
+
+	perr, ok := err.(*PartialWalkError)
+	assert.True(t, ok)
+	assert.Equal(t, ErrWalkDurationExceeded, perr.Err)
+}
+
+func TestClient_WalkSubtree_MaxRepetitions(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, testVars...)
+	defer agent.Close()
+
+	client := newTestClient(t, agent, "v2", SnmpWalkConfiguration{Mode: WalkBulk, MaxRepetitions: 2})
+	defer client.Close()
+
+	results, err := client.WalkSubtree("1.2.3")
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+	assert.Equal(t, 3, agent.Requests(gosnmp.GetBulkRequest))
+}
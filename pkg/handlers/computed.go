@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// computedReadHandlerFunc is the function which handles Reads for computed devices.
+// The values of all of the device's inputs are fetched in a single GET request and
+// passed to the device's compute function.
+func computedReadHandlerFunc(device *sdk.Device) ([]*output.Reading, error) {
+	if device == nil {
+		return nil, errors.New("unable to read from nil device")
+	}
+
+	// Get data cached in device.Data
+	agent, err := getAgent(device.Data)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := getInputs(device.Data)
+	if err != nil {
+		return nil, err
+	}
+	compute, err := getCompute(device.Data)
+	if err != nil {
+		return nil, err
+	}
+	targetConfig, err := getTargetConfig(device.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// A computed device only reports readings once the agent is known to
+	// support all of its inputs, and is gone if the agent no longer supports
+	// any of them.
+	target, err := getTarget(device.Data)
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		for _, oid := range inputs {
+			if target.IsRetired(oid) {
+				return nil, fmt.Errorf("unable to read input OID %s from agent %s: %w", oid, agent, core.ErrRetired)
+			}
+		}
+		for _, oid := range inputs {
+			if !target.IsSupported(oid) {
+				log.WithFields(log.Fields{
+					"agent": agent,
+					"oid":   oid,
+					"state": target.Status().State,
+				}).Debug("[snmp] computed device input not discovered for agent; no reading")
+				return nil, nil
+			}
+		}
+	}
+
+	// The values of all inputs are fetched together (see getValues).
+	results, err := getValues(target, targetConfig, agent, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(inputs))
+	for i, result := range results {
+		var decoder string
+		if target != nil {
+			decoder = target.Decoder(inputs[i])
+		}
+		value, err := core.DecodeValue(decoder, result)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	value, err := compute(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute device value: %v", err)
+	}
+
+	log.WithFields(log.Fields{
+		"inputs": values,
+		"value":  value,
+	}).Debug("[snmp] computed value")
+
+	o := output.Get(device.Output)
+	if o == nil {
+		return nil, fmt.Errorf("unable to format reading: device output not defined")
+	}
+
+	return []*output.Reading{
+		o.MakeReading(value).WithContext(device.Context),
+	}, nil
+}
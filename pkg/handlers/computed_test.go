@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// newComputedDevice creates a computed device which multiplies the values of two
+// inputs.
+func newComputedDevice(t *testing.T, agent string, target *core.Target) *sdk.Device {
+	compute, err := core.ParseExpression("$1 * $2", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:       "test-mib",
+		Version:   "v2",
+		Agent:     agent,
+		Community: "public",
+		Timeout:   100 * time.Millisecond,
+	}
+	data := map[string]interface{}{
+		"agent":      agent,
+		"key":        "power",
+		"inputs":     []string{"1.2.3.1", "1.2.3.2"},
+		"compute":    compute,
+		"target_cfg": cfg,
+	}
+	if target != nil {
+		data["target"] = target
+	}
+	return &sdk.Device{
+		Output:  "watt",
+		Data:    data,
+		Context: map[string]string{},
+	}
+}
+
+func TestComputedReadHandlerFunc(t *testing.T) {
+	agent, err := snmptest.NewAgent(
+		gosnmp.Version2c,
+		gosnmp.SnmpPDU{Name: "1.2.3.1", Type: gosnmp.Integer, Value: 230},
+		gosnmp.SnmpPDU{Name: "1.2.3.2", Type: gosnmp.OctetString, Value: []byte("1.5")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	readings, err := computedReadHandlerFunc(newComputedDevice(t, agent.Addr(), nil))
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+	assert.Equal(t, float64(345), readings[0].Value)
+
+	// Both inputs are fetched with a single request.
+	assert.Equal(t, 1, agent.Requests(gosnmp.GetRequest))
+}
+
+func TestComputedReadHandlerFunc_Seeded(t *testing.T) {
+	agent, err := snmptest.NewAgent(
+		gosnmp.Version2c,
+		gosnmp.SnmpPDU{Name: "1.2.3.1", Type: gosnmp.Integer, Value: 230},
+		gosnmp.SnmpPDU{Name: "1.2.3.2", Type: gosnmp.Integer, Value: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	device := newComputedDevice(t, agent.Addr(), nil)
+	target := core.NewTarget(device.Data["target_cfg"].(*core.SnmpTargetConfiguration), []string{"test-mib"})
+	target.Discovered(map[string]struct{}{"1.2.3.1": {}, "1.2.3.2": {}})
+	target.Seed(core.DiscoveredValues{
+		"1.2.3.1": {Name: ".1.2.3.1", Type: gosnmp.Integer, Value: 220},
+	})
+	device.Data["target"] = target
+
+	// Only the input without a discovered value is fetched.
+	readings, err := computedReadHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+	assert.Equal(t, float64(440), readings[0].Value)
+	assert.Equal(t, 1, agent.Requests(gosnmp.GetRequest))
+
+	readings, err = computedReadHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(460), readings[0].Value)
+	assert.Equal(t, 2, agent.Requests(gosnmp.GetRequest))
+}
+
+func TestComputedReadHandlerFunc_InputNotSupported(t *testing.T) {
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:     "test-mib",
+		Version: "v2",
+		Agent:   "udp://localhost:1024",
+	}
+	target := core.NewTarget(cfg, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{"1.2.3.1": {}})
+
+	readings, err := computedReadHandlerFunc(newComputedDevice(t, cfg.Agent, target))
+	assert.NoError(t, err)
+	assert.Nil(t, readings)
+}
+
+func TestComputedReadHandlerFunc_MissingInput(t *testing.T) {
+	agent, err := snmptest.NewAgent(
+		gosnmp.Version2c,
+		gosnmp.SnmpPDU{Name: "1.2.3.1", Type: gosnmp.Integer, Value: 230},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	readings, err := computedReadHandlerFunc(newComputedDevice(t, agent.Addr(), nil))
+	assert.Error(t, err)
+	assert.Nil(t, readings)
+}
+
+func TestComputedReadHandlerFunc_ComputeError(t *testing.T) {
+	agent, err := snmptest.NewAgent(
+		gosnmp.Version2c,
+		gosnmp.SnmpPDU{Name: "1.2.3.1", Type: gosnmp.Integer, Value: 230},
+		gosnmp.SnmpPDU{Name: "1.2.3.2", Type: gosnmp.Integer, Value: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	device := newComputedDevice(t, agent.Addr(), nil)
+	device.Data["compute"] = core.ComputeFunc(func(values []interface{}) (interface{}, error) {
+		return nil, errors.New("compute failed")
+	})
+
+	readings, err := computedReadHandlerFunc(device)
+	assert.EqualError(t, err, "failed to compute device value: compute failed")
+	assert.Nil(t, readings)
+}
+
+func TestComputedReadHandlerFunc_NilDevice(t *testing.T) {
+	readings, err := computedReadHandlerFunc(nil)
+	assert.Error(t, err)
+	assert.Nil(t, readings)
+}
+
+func TestComputedReadHandlerFunc_NoInputs(t *testing.T) {
+	readings, err := computedReadHandlerFunc(&sdk.Device{
+		Data: map[string]interface{}{
+			"agent": "localhost",
+		},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, readings)
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/vapor-ware/synse-sdk/sdk"
 )
 
@@ -16,3 +19,74 @@ var ReadWrite = sdk.DeviceHandler{
 	Read:  readHandlerFunc,
 	Write: writeHandlerFunc,
 }
+
+// Computed is an SNMP device handler for computed devices, whose readings are
+// computed from the values of one or more OIDs.
+var Computed = sdk.DeviceHandler{
+	Name: "computed",
+	Read: computedReadHandlerFunc,
+}
+
+// AgentStatus is an SNMP device handler for agent status devices, which report
+// the reachability and latency of an SNMP agent.
+var AgentStatus = sdk.DeviceHandler{
+	Name: "agent-status",
+	Read: statusReadHandlerFunc,
+}
+
+var (
+	handlersMu sync.RWMutex
+
+	// pluginHandlers holds the device handlers known to the SNMP plugin base,
+	// in the order in which they were registered.
+	pluginHandlers = []*sdk.DeviceHandler{
+		&ReadOnly,
+		&ReadWrite,
+		&Computed,
+		&AgentStatus,
+	}
+)
+
+// Register adds custom device handlers to the set of handlers known to the SNMP
+// plugin base. Handlers registered here are registered with the plugin by
+// NewSnmpBasePlugin and are considered valid handler names when validating MIBs.
+//
+// Custom handlers should be registered before any MIBs which use them.
+func Register(handlers ...*sdk.DeviceHandler) error {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	for _, h := range handlers {
+		for _, existing := range pluginHandlers {
+			if existing.Name == h.Name {
+				return fmt.Errorf("device handler already registered: %s", h.Name)
+			}
+		}
+		pluginHandlers = append(pluginHandlers, h)
+	}
+	return nil
+}
+
+// Get gets the device handler with the given name. If no such handler is known
+// to the SNMP plugin base, nil is returned.
+func Get(name string) *sdk.DeviceHandler {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+
+	for _, h := range pluginHandlers {
+		if h.Name == name {
+			return h
+		}
+	}
+	return nil
+}
+
+// All gets all of the device handlers known to the SNMP plugin base.
+func All() []*sdk.DeviceHandler {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+
+	all := make([]*sdk.DeviceHandler, len(pluginHandlers))
+	copy(all, pluginHandlers)
+	return all
+}
@@ -1 +1,51 @@
 package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-sdk/sdk"
+)
+
+func resetHandlers() {
+	pluginHandlers = []*sdk.DeviceHandler{
+		&ReadOnly,
+		&ReadWrite,
+		&Computed,
+		&AgentStatus,
+	}
+}
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, &ReadOnly, Get("read-only"))
+	assert.Equal(t, &ReadWrite, Get("read-write"))
+	assert.Equal(t, &Computed, Get("computed"))
+	assert.Equal(t, &AgentStatus, Get("agent-status"))
+	assert.Nil(t, Get("unknown"))
+}
+
+func TestAll(t *testing.T) {
+	all := All()
+	assert.Len(t, all, 4)
+	assert.Equal(t, "read-only", all[0].Name)
+	assert.Equal(t, "read-write", all[1].Name)
+	assert.Equal(t, "computed", all[2].Name)
+	assert.Equal(t, "agent-status", all[3].Name)
+}
+
+func TestRegister(t *testing.T) {
+	defer resetHandlers()
+
+	err := Register(&sdk.DeviceHandler{Name: "custom"})
+	assert.NoError(t, err)
+	assert.NotNil(t, Get("custom"))
+	assert.Len(t, All(), 5)
+}
+
+func TestRegister_Exists(t *testing.T) {
+	defer resetHandlers()
+
+	err := Register(&sdk.DeviceHandler{Name: "read-only"})
+	assert.Error(t, err)
+	assert.Len(t, All(), 4)
+}
@@ -31,44 +31,62 @@ func readHandlerFunc(device *sdk.Device) ([]*output.Reading, error) {
 		return nil, err
 	}
 
-	// Create a new client with the target configuration.
-	c, err := core.NewClient(targetConfig)
+	// If the device was registered before discovery succeeded for its target
+	// (deferred registration), it does not report readings until the agent is
+	// known to support it. If the agent no longer supports it, it is gone.
+	target, err := getTarget(device.Data)
 	if err != nil {
 		return nil, err
 	}
-	defer c.Close()
+	if target != nil && target.IsRetired(oid) {
+		return nil, fmt.Errorf("unable to read OID %s from agent %s: %w", oid, agent, core.ErrRetired)
+	}
+	if target != nil && !target.IsSupported(oid) {
+		log.WithFields(log.Fields{
+			"agent": agent,
+			"oid":   oid,
+			"state": target.Status().State,
+		}).Debug("[snmp] OID not discovered for agent; no reading")
+		return nil, nil
+	}
 
-	log.WithFields(log.Fields{
-		"agent": agent,
-		"oid":   oid,
-	}).Debug("[snmp] reading OID")
+	// Devices may report additional readings. Those whose OID is not known to
+	// be supported by the agent are left out.
+	extras, err := getReadings(device.Data)
+	if err != nil {
+		return nil, err
+	}
+	oids := []string{oid}
+	var readingOids []*core.ReadingOid
+	for _, r := range extras {
+		if target != nil && !target.IsSupported(r.OID) {
+			log.WithFields(log.Fields{
+				"agent": agent,
+				"oid":   r.OID,
+			}).Debug("[snmp] reading OID not discovered for agent; skipping reading")
+			continue
+		}
+		oids = append(oids, r.OID)
+		readingOids = append(readingOids, r)
+	}
 
-	result, err := c.GetOid(oid)
+	results, err := getValues(target, targetConfig, agent, oids)
 	if err != nil {
 		return nil, err
 	}
 
+	result := results[0]
 	log.WithFields(log.Fields{
 		"value": result.Value,
 		"name":  result.Name,
 		"type":  result.Type,
 	}).Debug("[snmp] got reading value for OID")
 
-	var value interface{}
-	switch result.Type {
-	case gosnmp.OctetString:
-		ascii, err := core.BytesIfaceToASCII(result.Value)
-		if err != nil {
-			return nil, err
-		}
-		value = ascii
-	default:
-		value = result.Value
+	var decoder string
+	if target != nil {
+		decoder = target.Decoder(oid)
 	}
-
-	// Check if the device has enumerated values. If so, an "enum" map is present
-	// in the device Data. This is set via the device config.
-	value, err = parseEnum(device.Data, value)
+	value, err := decodeValue(device.Data, decoder, result)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +100,116 @@ func readHandlerFunc(device *sdk.Device) ([]*output.Reading, error) {
 		return nil, fmt.Errorf("unable to format reading: device output not defined")
 	}
 
-	return []*output.Reading{
+	readings := []*output.Reading{
 		o.MakeReading(value).WithContext(device.Context),
-	}, nil
+	}
+	for i, r := range readingOids {
+		reading, err := makeReading(device, target, r, results[i+1])
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+// makeReading makes the reading for one of a device's additional reading OIDs. The
+// reading's context is the device context, with the reading's own context and OID
+// added.
+func makeReading(device *sdk.Device, target *core.Target, r *core.ReadingOid, result gosnmp.SnmpPDU) (*output.Reading, error) {
+	var decoder string
+	if target != nil {
+		decoder = target.Decoder(r.OID)
+	}
+	value, err := core.DecodeValue(decoder, result)
+	if err != nil {
+		return nil, err
+	}
+
+	o := output.Get(r.Output)
+	if o == nil {
+		return nil, fmt.Errorf("unable to format reading: output '%s' not defined", r.Output)
+	}
+
+	context := map[string]string{}
+	for k, v := range device.Context {
+		context[k] = v
+	}
+	for k, v := range r.Context {
+		context[k] = v
+	}
+	context["oid"] = r.OID
+	return o.MakeReading(value).WithContext(context), nil
+}
+
+// getValues gets the values of the given OIDs for a device, in order. The values the
+// agent returned during discovery are used for a device's first reading, if there
+// are any (see core.Target.TakeSeed); the values of the remaining OIDs are fetched
+// from the agent in a single GET request.
+func getValues(target *core.Target, targetConfig *core.SnmpTargetConfiguration, agent string, oids []string) ([]gosnmp.SnmpPDU, error) {
+	results := make([]gosnmp.SnmpPDU, len(oids))
+	var missing []int
+	for i, oid := range oids {
+		var seeded bool
+		if target != nil {
+			results[i], seeded = target.TakeSeed(oid)
+		}
+		if seeded {
+			log.WithFields(log.Fields{
+				"agent": agent,
+				"oid":   oid,
+			}).Debug("[snmp] using discovered value for OID")
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	// Create a new client with the target configuration. Clients for a target
+	// record the outcome of their requests in the health of its agent.
+	var c *core.Client
+	var err error
+	if target != nil {
+		c, err = target.NewClient()
+	} else {
+		c, err = core.NewClient(targetConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	get := make([]string, len(missing))
+	for i, idx := range missing {
+		get[i] = oids[idx]
+	}
+	log.WithFields(log.Fields{
+		"agent": agent,
+		"oids":  get,
+	}).Debug("[snmp] reading OIDs")
+
+	r, err := c.GetOids(get...)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range missing {
+		results[idx] = r[i]
+	}
+	return results, nil
+}
+
+// decodeValue gets the reading value for an SNMP variable with the given decoder
+// (see core.DecodeValue). Values are mapped to their enumerated values if the
+// device defines any.
+func decodeValue(data map[string]interface{}, decoder string, result gosnmp.SnmpPDU) (interface{}, error) {
+	value, err := core.DecodeValue(decoder, result)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the device has enumerated values. If so, an "enum" map is present
+	// in the device Data. This is set via the device config.
+	return parseEnum(data, value)
 }
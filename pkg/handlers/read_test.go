@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/soniah/gosnmp"
 	"github.com/stretchr/testify/assert"
 	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
 	"github.com/vapor-ware/synse-snmp-base/pkg/core"
 )
 
@@ -66,6 +70,139 @@ func TestReadHandlerFunc_FailedClientInit(t *testing.T) {
 	assert.Nil(t, readings)
 }
 
+func TestReadHandlerFunc_TargetNotDiscovered(t *testing.T) {
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:     "test-mib",
+		Version: "v2",
+		Agent:   "udp://localhost:1024",
+	}
+
+	readings, err := readHandlerFunc(&sdk.Device{
+		Data: map[string]interface{}{
+			"agent":      "udp://localhost:1024",
+			"oid":        "1.2.3.4",
+			"target_cfg": cfg,
+			"target":     core.NewTarget(cfg, []string{"test-mib"}),
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, readings)
+}
+
+func TestReadHandlerFunc_TargetOidNotSupported(t *testing.T) {
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:     "test-mib",
+		Version: "v2",
+		Agent:   "udp://localhost:1024",
+	}
+	target := core.NewTarget(cfg, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{"1.2.3.5": {}})
+
+	readings, err := readHandlerFunc(&sdk.Device{
+		Data: map[string]interface{}{
+			"agent":      "udp://localhost:1024",
+			"oid":        "1.2.3.4",
+			"target_cfg": cfg,
+			"target":     target,
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, readings)
+}
+
+func TestReadHandlerFunc_TargetOidRetired(t *testing.T) {
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:     "test-mib",
+		Version: "v2",
+		Agent:   "udp://localhost:1024",
+	}
+	target := core.NewTarget(cfg, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}, "1.2.3.5": {}})
+	target.Discovered(map[string]struct{}{"1.2.3.5": {}})
+
+	readings, err := readHandlerFunc(&sdk.Device{
+		Data: map[string]interface{}{
+			"agent":      "udp://localhost:1024",
+			"oid":        "1.2.3.4",
+			"target_cfg": cfg,
+			"target":     target,
+		},
+	})
+
+	assert.True(t, errors.Is(err, core.ErrRetired))
+	assert.Nil(t, readings)
+}
+
+func TestReadHandlerFunc_Seeded(t *testing.T) {
+	// Nothing listens at the agent address, so the reading must come from the
+	// value seeded during discovery.
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:       "test-mib",
+		Version:   "v2",
+		Agent:     "udp://localhost:1024",
+		Community: "public",
+		Timeout:   100 * time.Millisecond,
+	}
+	target := core.NewTarget(cfg, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}})
+	target.Seed(core.DiscoveredValues{
+		"1.2.3.4": {Name: ".1.2.3.4", Type: gosnmp.OctetString, Value: []byte("ok")},
+	})
+
+	device := &sdk.Device{
+		Output: "status",
+		Data: map[string]interface{}{
+			"agent":      cfg.Agent,
+			"oid":        "1.2.3.4",
+			"target_cfg": cfg,
+			"target":     target,
+		},
+		Context: map[string]string{},
+	}
+
+	readings, err := readHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+	assert.Equal(t, "ok", readings[0].Value)
+
+	// The seed is only used for the first reading; the next one gets the OID
+	// from the agent.
+	_, err = readHandlerFunc(device)
+	assert.Error(t, err)
+}
+
+func TestReadHandlerFunc_Decoder(t *testing.T) {
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:       "test-mib",
+		Version:   "v2",
+		Agent:     "udp://localhost:1024",
+		Community: "public",
+		Timeout:   100 * time.Millisecond,
+	}
+	target := core.NewTarget(cfg, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}})
+	target.SetDecoders(map[string]string{"1.2.3.4": core.DecoderNumericString})
+	target.Seed(core.DiscoveredValues{
+		"1.2.3.4": {Name: ".1.2.3.4", Type: gosnmp.OctetString, Value: []byte("23.5")},
+	})
+
+	readings, err := readHandlerFunc(&sdk.Device{
+		Output: "temperature",
+		Data: map[string]interface{}{
+			"agent":      cfg.Agent,
+			"oid":        "1.2.3.4",
+			"target_cfg": cfg,
+			"target":     target,
+		},
+		Context: map[string]string{},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+	assert.Equal(t, 23.5, readings[0].Value)
+}
+
 //
 // Integration tests
 //
@@ -113,6 +250,101 @@ func getReadTestDevice(oid string) *sdk.Device {
 	}
 }
 
+func TestReadHandlerFunc_MultipleReadings(t *testing.T) {
+	agent, err := snmptest.NewAgent(
+		gosnmp.Version2c,
+		gosnmp.SnmpPDU{Name: "1.2.3.4", Type: gosnmp.Integer, Value: 1},
+		gosnmp.SnmpPDU{Name: "1.2.3.5", Type: gosnmp.Integer, Value: 2},
+		gosnmp.SnmpPDU{Name: "1.2.3.6", Type: gosnmp.Integer, Value: 230},
+		gosnmp.SnmpPDU{Name: "1.2.3.7", Type: gosnmp.Integer, Value: 9},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:       "test-mib",
+		Version:   "v2",
+		Agent:     agent.Addr(),
+		Community: "public",
+		Timeout:   100 * time.Millisecond,
+	}
+	target := core.NewTarget(cfg, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{"1.2.3.4": {}, "1.2.3.5": {}, "1.2.3.6": {}})
+
+	device := &sdk.Device{
+		Output: "status",
+		Data: map[string]interface{}{
+			"agent":      cfg.Agent,
+			"oid":        "1.2.3.4",
+			"target_cfg": cfg,
+			"target":     target,
+			"enum": map[interface{}]interface{}{
+				1: "on",
+				2: "off",
+			},
+			"readings": []*core.ReadingOid{
+				{OID: "1.2.3.5", Output: "electric-current"},
+				{OID: "1.2.3.6", Output: "voltage", Context: map[string]string{"phase": "L1"}},
+				{OID: "1.2.3.7", Output: "watt"},
+			},
+		},
+		Context: map[string]string{"oid": "1.2.3.4", "outlet": "1"},
+	}
+
+	// The reading OID which was not discovered is left out.
+	readings, err := readHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 3)
+	assert.Equal(t, 1, agent.Requests(gosnmp.GetRequest))
+
+	assert.Equal(t, "on", readings[0].Value)
+	assert.Equal(t, map[string]string{"oid": "1.2.3.4", "outlet": "1"}, readings[0].Context)
+
+	// Enumerations only apply to the device's own OID.
+	assert.Equal(t, 2, readings[1].Value)
+	assert.Equal(t, "ampere", readings[1].Unit.Name)
+	assert.Equal(t, map[string]string{"oid": "1.2.3.5", "outlet": "1"}, readings[1].Context)
+
+	assert.Equal(t, 230, readings[2].Value)
+	assert.Equal(t, map[string]string{"oid": "1.2.3.6", "outlet": "1", "phase": "L1"}, readings[2].Context)
+
+	// The device context is not modified.
+	assert.Equal(t, map[string]string{"oid": "1.2.3.4", "outlet": "1"}, device.Context)
+}
+
+func TestReadHandlerFunc_BadReadingOutput(t *testing.T) {
+	agent, err := snmptest.NewAgent(
+		gosnmp.Version2c,
+		gosnmp.SnmpPDU{Name: "1.2.3.4", Type: gosnmp.Integer, Value: 1},
+		gosnmp.SnmpPDU{Name: "1.2.3.5", Type: gosnmp.Integer, Value: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agent.Close()
+
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:       "test-mib",
+		Version:   "v2",
+		Agent:     agent.Addr(),
+		Community: "public",
+		Timeout:   100 * time.Millisecond,
+	}
+	readings, err := readHandlerFunc(&sdk.Device{
+		Output: "status",
+		Data: map[string]interface{}{
+			"agent":      cfg.Agent,
+			"oid":        "1.2.3.4",
+			"target_cfg": cfg,
+			"readings":   []*core.ReadingOid{{OID: "1.2.3.5", Output: "unknown"}},
+		},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, readings)
+}
+
 func TestReadHandlerFuncIdentStringIntegration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test: --short flag set")
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+)
+
+// statusReadHandlerFunc is the function which handles Reads for agent status devices.
+// The readings are taken from the health of the device's target's agent (see
+// core.Target.Health), which is maintained from the target's traffic to the agent,
+// so reading the status does not make any requests to the agent.
+//
+// The agent's reachability and the number of times it was detected to have restarted
+// are always reported. Its last response time and rolling latency are only reported
+// once the agent has responded.
+func statusReadHandlerFunc(device *sdk.Device) ([]*output.Reading, error) {
+	if device == nil {
+		return nil, errors.New("unable to read from nil device")
+	}
+
+	target, err := getTarget(device.Data)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, errors.New("unable to read agent status: device has no target")
+	}
+	status := target.Health().Status()
+
+	o := output.Get(device.Output)
+	if o == nil {
+		return nil, fmt.Errorf("unable to format reading: device output not defined")
+	}
+	readings := []*output.Reading{
+		o.MakeReading(status.State).WithContext(statusContext(device, "reachability")),
+		output.Count.MakeReading(status.Restarts).WithContext(statusContext(device, "restarts")),
+	}
+	if status.LastResponse.IsZero() {
+		return readings, nil
+	}
+
+	readings = append(readings,
+		output.Timestamp.MakeReading(status.LastResponse.Format(time.RFC3339Nano)).WithContext(statusContext(device, "last-response")),
+		output.Milliseconds.MakeReading(float64(status.Latency)/float64(time.Millisecond)).WithContext(statusContext(device, "latency")),
+	)
+	return readings, nil
+}
+
+// statusContext gets the context of an agent status reading, which is the device
+// context with the name of the reading added.
+func statusContext(device *sdk.Device, reading string) map[string]string {
+	context := map[string]string{}
+	for k, v := range device.Context {
+		context[k] = v
+	}
+	context["reading"] = reading
+	return context
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// newStatusDevice creates an agent status device for a new target for the given
+// agent, returning the device and the health of the target's agent.
+func newStatusDevice(agent string) (*sdk.Device, *core.AgentHealth) {
+	target := core.NewTarget(&core.SnmpTargetConfiguration{Agent: agent}, nil)
+	return &sdk.Device{
+		Output:  "status",
+		Data:    map[string]interface{}{"agent": agent, "target": target},
+		Context: map[string]string{"agent": agent},
+	}, target.Health()
+}
+
+func TestStatusReadHandlerFunc_Unknown(t *testing.T) {
+	device, _ := newStatusDevice("status-test-unknown")
+	readings, err := statusReadHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 2)
+	assert.Equal(t, core.AgentUnknown, readings[0].Value)
+	assert.Equal(t, "status", readings[0].Type)
+	assert.Equal(t, "reachability", readings[0].Context["reading"])
+	assert.Equal(t, "status-test-unknown", readings[0].Context["agent"])
+	assert.Equal(t, 0, readings[1].Value)
+	assert.Equal(t, "restarts", readings[1].Context["reading"])
+}
+
+func TestStatusReadHandlerFunc(t *testing.T) {
+	device, health := newStatusDevice("status-test-reachable")
+	health.Observe(20*time.Millisecond, nil)
+	health.Observe(40*time.Millisecond, nil)
+
+	readings, err := statusReadHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 4)
+
+	assert.Equal(t, core.AgentReachable, readings[0].Value)
+	assert.Equal(t, "reachability", readings[0].Context["reading"])
+
+	assert.Equal(t, "timestamp", readings[2].Type)
+	assert.Equal(t, health.Status().LastResponse.Format(time.RFC3339Nano), readings[2].Value)
+	assert.Equal(t, "last-response", readings[2].Context["reading"])
+
+	assert.Equal(t, "duration", readings[3].Type)
+	assert.Equal(t, float64(30), readings[3].Value)
+	assert.Equal(t, "latency", readings[3].Context["reading"])
+	assert.Equal(t, "status-test-reachable", readings[3].Context["agent"])
+}
+
+func TestStatusReadHandlerFunc_Restarts(t *testing.T) {
+	device, health := newStatusDevice("status-test-restarts")
+	health.ObserveUptime(5000)
+	health.ObserveUptime(100)
+
+	readings, err := statusReadHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 2)
+	assert.Equal(t, "count", readings[1].Type)
+	assert.Equal(t, 1, readings[1].Value)
+	assert.Equal(t, "restarts", readings[1].Context["reading"])
+}
+
+func TestStatusReadHandlerFunc_Unreachable(t *testing.T) {
+	device, health := newStatusDevice("status-test-unreachable")
+	health.Observe(20*time.Millisecond, nil)
+	health.Observe(time.Second, errors.New("request timeout"))
+
+	readings, err := statusReadHandlerFunc(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 4)
+	assert.Equal(t, core.AgentUnreachable, readings[0].Value)
+	assert.Equal(t, float64(20), readings[3].Value)
+}
+
+func TestStatusReadHandlerFunc_NilDevice(t *testing.T) {
+	readings, err := statusReadHandlerFunc(nil)
+	assert.Error(t, err)
+	assert.Nil(t, readings)
+}
+
+func TestStatusReadHandlerFunc_NoTarget(t *testing.T) {
+	readings, err := statusReadHandlerFunc(&sdk.Device{Output: "status", Data: map[string]interface{}{}})
+	assert.Error(t, err)
+	assert.Nil(t, readings)
+}
@@ -61,6 +61,24 @@ func getTargetConfig(data map[string]interface{}) (*core.SnmpTargetConfiguration
 	return cfg, nil
 }
 
+// getTarget is a convenience function to get the "target" value out of a device's
+// Data field, if it exists.
+//
+// Unlike the other fields, the "target" field is optional, as it is only set for
+// devices which are registered dynamically. If it does not exist, nil is returned.
+// If it exists but cannot be cast to a Target, an error is returned.
+func getTarget(data map[string]interface{}) (*core.Target, error) {
+	targetIface, exists := data["target"]
+	if !exists {
+		return nil, nil
+	}
+	target, ok := targetIface.(*core.Target)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast 'target' value (%T) to Target", targetIface)
+	}
+	return target, nil
+}
+
 // parseEnum checks to see if the device value is an enumeration, and if so, converts
 // the value to the corresponding enumeration value based on a lookup table defined
 // in the device Data.
@@ -91,3 +109,60 @@ func parseEnum(data map[string]interface{}, value interface{}) (interface{}, err
 	}).Debug("[snmp] using enumeration value")
 	return val, nil
 }
+
+// getInputs is a convenience function to safely get the "inputs" value out of a computed
+// device's Data field and cast it to the appropriate type.
+//
+// Since the "inputs" field is expected to exist in the device Data and it is expected to
+// be a non-empty slice of OIDs, this function returns an error if it does not exist, is
+// empty, or cannot be cast to a slice of strings.
+func getInputs(data map[string]interface{}) ([]string, error) {
+	inputsIface, exists := data["inputs"]
+	if !exists {
+		return nil, fmt.Errorf("expected field 'inputs' in device data, but not found")
+	}
+	inputs, ok := inputsIface.([]string)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast 'inputs' value (%T) to []string", inputsIface)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("computed device has no inputs")
+	}
+	return inputs, nil
+}
+
+// getCompute is a convenience function to safely get the "compute" value out of a computed
+// device's Data field and cast it to the appropriate type.
+//
+// Since the "compute" field is expected to exist in the device Data and it is expected to
+// be a ComputeFunc, this function returns an error if it does not exist or cannot be cast
+// to a ComputeFunc.
+func getCompute(data map[string]interface{}) (core.ComputeFunc, error) {
+	computeIface, exists := data["compute"]
+	if !exists {
+		return nil, fmt.Errorf("expected field 'compute' in device data, but not found")
+	}
+	compute, ok := computeIface.(core.ComputeFunc)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast 'compute' value (%T) to ComputeFunc", computeIface)
+	}
+	return compute, nil
+}
+
+// getReadings is a convenience function to get the "readings" value out of a device's
+// Data field, if it exists.
+//
+// The "readings" field is optional, as it is only set for devices which report more
+// than one reading. If it does not exist, nil is returned. If it exists but cannot be
+// cast to a slice of ReadingOids, an error is returned.
+func getReadings(data map[string]interface{}) ([]*core.ReadingOid, error) {
+	readingsIface, exists := data["readings"]
+	if !exists {
+		return nil, nil
+	}
+	readings, ok := readingsIface.([]*core.ReadingOid)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast 'readings' value (%T) to []*ReadingOid", readingsIface)
+	}
+	return readings, nil
+}
@@ -135,3 +135,106 @@ func TestParseEnum_NoEnumValue(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, val)
 }
+
+func TestGetTarget(t *testing.T) {
+	target := core.NewTarget(&core.SnmpTargetConfiguration{}, nil)
+	data := map[string]interface{}{
+		"target": target,
+	}
+
+	tgt, err := getTarget(data)
+	assert.NoError(t, err)
+	assert.Equal(t, target, tgt)
+}
+
+func TestGetTarget_NotExist(t *testing.T) {
+	data := map[string]interface{}{}
+
+	tgt, err := getTarget(data)
+	assert.NoError(t, err)
+	assert.Nil(t, tgt)
+}
+
+func TestGetTarget_BadType(t *testing.T) {
+	data := map[string]interface{}{
+		"target": 1234,
+	}
+
+	tgt, err := getTarget(data)
+	assert.Error(t, err)
+	assert.Nil(t, tgt)
+}
+
+func TestGetInputs(t *testing.T) {
+	data := map[string]interface{}{
+		"inputs": []string{"1.2.3.4", "1.2.3.5"},
+	}
+
+	inputs, err := getInputs(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.5"}, inputs)
+}
+
+func TestGetInputs_NotExist(t *testing.T) {
+	inputs, err := getInputs(map[string]interface{}{})
+	assert.Error(t, err)
+	assert.Nil(t, inputs)
+}
+
+func TestGetInputs_BadType(t *testing.T) {
+	inputs, err := getInputs(map[string]interface{}{"inputs": "1.2.3.4"})
+	assert.Error(t, err)
+	assert.Nil(t, inputs)
+}
+
+func TestGetInputs_Empty(t *testing.T) {
+	inputs, err := getInputs(map[string]interface{}{"inputs": []string{}})
+	assert.Error(t, err)
+	assert.Nil(t, inputs)
+}
+
+func TestGetCompute(t *testing.T) {
+	data := map[string]interface{}{
+		"compute": core.ComputeFunc(func(values []interface{}) (interface{}, error) {
+			return 1, nil
+		}),
+	}
+
+	compute, err := getCompute(data)
+	assert.NoError(t, err)
+	assert.NotNil(t, compute)
+}
+
+func TestGetCompute_NotExist(t *testing.T) {
+	compute, err := getCompute(map[string]interface{}{})
+	assert.Error(t, err)
+	assert.Nil(t, compute)
+}
+
+func TestGetCompute_BadType(t *testing.T) {
+	compute, err := getCompute(map[string]interface{}{"compute": "$1"})
+	assert.Error(t, err)
+	assert.Nil(t, compute)
+}
+
+func TestGetReadings(t *testing.T) {
+	data := map[string]interface{}{
+		"readings": []*core.ReadingOid{{OID: "1.2.3.5", Output: "electric-current"}},
+	}
+
+	readings, err := getReadings(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []*core.ReadingOid{{OID: "1.2.3.5", Output: "electric-current"}}, readings)
+}
+
+func TestGetReadings_NotExist(t *testing.T) {
+	readings, err := getReadings(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Nil(t, readings)
+}
+
+func TestGetReadings_BadType(t *testing.T) {
+	readings, err := getReadings(map[string]interface{}{"readings": []string{"1.2.3.5"}})
+	assert.Error(t, err)
+	assert.Nil(t, readings)
+}
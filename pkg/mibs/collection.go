@@ -2,24 +2,49 @@ package mibs
 
 import (
 	"errors"
+	"sort"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
 )
 
-// pluginMibs holds the collection of MIBs which have been registered with the
-// SNMP plugin base.
-var pluginMibs = map[string]*MIB{}
+// DefaultRegistry is the MIB registry used by the package-level functions, and by
+// the SNMP base plugin when no other registry is specified.
+var DefaultRegistry = NewRegistry()
 
 // Errors for SNMP base plugin MIB operations.
 var (
-	ErrMibExists = errors.New("MIB already registered")
-	ErrNilMib    = errors.New("MIB cannot be nil")
+	ErrMibExists   = errors.New("MIB already registered")
+	ErrMibNotFound = errors.New("MIB not registered")
+	ErrNilMib      = errors.New("MIB cannot be nil")
 )
 
-// Register MIBs defined by a plugin implementation with the SNMP base plugin.
+// Registry holds a collection of MIBs which have been registered with the SNMP
+// plugin base. It is safe for concurrent use.
+type Registry struct {
+	mu   sync.RWMutex
+	mibs map[string]*MIB
+}
+
+// NewRegistry creates a new, empty MIB registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		mibs: map[string]*MIB{},
+	}
+}
+
+// Register MIBs defined by a plugin implementation with the registry.
 //
-// If a MIB with the same name is already registered, an error is returned.
-func Register(mibs ...*MIB) error {
+// If a MIB with the same name is already registered, an error is returned. Each
+// MIB is validated (see MIB.Validate) prior to registration; if a MIB definition
+// is invalid, the returned error lists all of the problems with it. MIBs which
+// extend other MIBs must be registered after the MIBs they extend, as the
+// resolved device set is computed at registration time.
+func (r *Registry) Register(mibs ...*MIB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	for _, mib := range mibs {
 		if mib == nil {
 			log.Error("[snmp] cannot register nil MIB")
@@ -28,14 +53,83 @@ func Register(mibs ...*MIB) error {
 
 		// Check if the MIB being registered conflicts with a MIB that has already
 		// been registered.
-		if _, exists := pluginMibs[mib.Name]; exists {
+		if _, exists := r.mibs[mib.Name]; exists {
 			log.WithFields(log.Fields{
 				"mib": mib.Name,
 			}).Error("[snmp] unable to register MIB; conflicts with existing MIB")
 			return ErrMibExists
 		}
 
-		pluginMibs[mib.Name] = mib
+		res, err := r.prepare(mib, r.lookup)
+		if err != nil {
+			return err
+		}
+		mib.resolution.Store(res)
+		r.mibs[mib.Name] = mib
+	}
+	return nil
+}
+
+// Replace registers the given MIB, replacing any MIB already registered with the
+// same name. Any registered MIBs which extend the replaced MIB, directly or
+// transitively, are re-resolved against the new definition.
+//
+// The replacement is atomic: the new MIB and all of its dependents are resolved and
+// validated before any of them change, so if any of them fails, an error is returned
+// and the registry is left as it was.
+func (r *Registry) Replace(mib *MIB) error {
+	if mib == nil {
+		log.Error("[snmp] cannot register nil MIB")
+		return ErrNilMib
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// MIBs are looked up in their staged form while resolving, so each dependent
+	// is resolved against the resolutions which will be applied.
+	staged := map[string]*MIB{}
+	lookup := func(name string) *MIB {
+		if m, exists := staged[name]; exists {
+			return m
+		}
+		return r.mibs[name]
+	}
+
+	resolutions := map[*MIB]*resolution{}
+	for _, m := range append([]*MIB{mib}, r.dependents(mib.Name)...) {
+		res, err := r.prepare(m, lookup)
+		if err != nil {
+			return err
+		}
+		staged[m.Name] = m.staged(res)
+		resolutions[m] = res
+	}
+
+	for m, res := range resolutions {
+		m.resolution.Store(res)
+	}
+	r.mibs[mib.Name] = mib
+	return nil
+}
+
+// Unregister removes the MIBs with the given names from the registry. If any of
+// the named MIBs is not registered, ErrMibNotFound is returned.
+//
+// MIBs which extend an unregistered MIB keep the device set which was resolved
+// when they were registered.
+func (r *Registry) Unregister(names ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range names {
+		if _, exists := r.mibs[name]; !exists {
+			log.WithFields(log.Fields{
+				"mib": name,
+			}).Error("[snmp] unable to unregister MIB; not registered")
+			return ErrMibNotFound
+		}
+		delete(r.mibs, name)
 	}
 	return nil
 }
@@ -43,27 +137,175 @@ func Register(mibs ...*MIB) error {
 // Get a registered MIB with the given name.
 //
 // If there is no MIB registered with the provided name, nil is returned.
-func Get(name string) *MIB {
-	return pluginMibs[name]
+func (r *Registry) Get(name string) *MIB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.mibs[name]
 }
 
-// GetAll returns all of the MIBs which have been registered with the SNMP base
-// plugin. The order in which the MIBs are returned is not guaranteed. If no MIBs
-// have been registered, an empty slice is returned.
-func GetAll() []*MIB {
-	mibs := make([]*MIB, len(pluginMibs))
-	i := 0
-	for _, mib := range pluginMibs {
-		mibs[i] = mib
-		i++
+// GetAll returns all of the MIBs which have been registered, sorted by name.
+// If no MIBs have been registered, an empty slice is returned.
+func (r *Registry) GetAll() []*MIB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mibs := make([]*MIB, 0, len(r.mibs))
+	for _, mib := range r.mibs {
+		mibs = append(mibs, mib)
 	}
+	sort.Slice(mibs, func(i, j int) bool {
+		return mibs[i].Name < mibs[j].Name
+	})
 	return mibs
 }
 
-// Clear removes all data from the global MIB collection.
+// Detect returns all registered MIBs which are implemented by an agent with the
+// given identity. The MIBs are returned sorted by name. If no MIBs match, an empty
+// slice is returned.
+func (r *Registry) Detect(identity *core.AgentIdentity) []*MIB {
+	var matches []*MIB
+	for _, mib := range r.GetAll() {
+		if mib.Matches(identity) {
+			matches = append(matches, mib)
+		}
+	}
+	return matches
+}
+
+// Clear removes all MIBs from the registry.
+func (r *Registry) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.mibs = map[string]*MIB{}
+}
+
+// prepare resolves and validates a MIB prior to it being added to the registry,
+// returning its resolution (see MIB.resolve). The MIB is validated in its staged form
+// and is not modified. The caller must hold the registry lock.
+func (r *Registry) prepare(mib *MIB, lookup func(string) *MIB) (*resolution, error) {
+	// Compute the device set for MIBs which build on other MIBs.
+	res, err := mib.resolve(lookup)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"mib": mib.Name,
+		}).Error("[snmp] unable to register MIB; failed to resolve devices")
+		return nil, err
+	}
+
+	// Ensure that the resolved MIB definition is valid.
+	if err := mib.staged(res).Validate(); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"mib": mib.Name,
+		}).Error("[snmp] unable to register MIB; invalid definition")
+		return nil, err
+	}
+	return res, nil
+}
+
+// dependents gets the registered MIBs which extend the named MIB, directly or
+// transitively, ordered so that each MIB comes after the dependents it extends.
+// The caller must hold the registry lock.
+func (r *Registry) dependents(name string) []*MIB {
+	pending := map[string]*MIB{}
+	var collect func(string)
+	collect = func(parent string) {
+		for _, mib := range r.mibs {
+			if _, exists := pending[mib.Name]; exists || mib.Name == name {
+				continue
+			}
+			for _, p := range mib.Extends {
+				if p == parent {
+					pending[mib.Name] = mib
+					collect(mib.Name)
+					break
+				}
+			}
+		}
+	}
+	collect(name)
+
+	var names []string
+	for n := range pending {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var ordered []*MIB
+	for len(pending) != 0 {
+		progress := false
+		for _, n := range names {
+			mib, exists := pending[n]
+			if !exists || extendsAny(mib, pending) {
+				continue
+			}
+			ordered = append(ordered, mib)
+			delete(pending, n)
+			progress = true
+		}
+		// A replaced MIB may have made dependents extend each other circularly;
+		// those are resolved in name order.
+		if !progress {
+			for _, n := range names {
+				if mib, exists := pending[n]; exists {
+					ordered = append(ordered, mib)
+				}
+			}
+			break
+		}
+	}
+	return ordered
+}
+
+// extendsAny checks whether the MIB extends any of the given MIBs.
+func extendsAny(mib *MIB, mibs map[string]*MIB) bool {
+	for _, name := range mib.Extends {
+		if _, exists := mibs[name]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup gets a registered MIB by name. The caller must hold the registry lock.
+func (r *Registry) lookup(name string) *MIB {
+	return r.mibs[name]
+}
+
+// Register MIBs defined by a plugin implementation with the default registry.
+//
+// See Registry.Register for details.
+func Register(mibs ...*MIB) error {
+	return DefaultRegistry.Register(mibs...)
+}
+
+// Get a MIB with the given name from the default registry.
+//
+// If there is no MIB registered with the provided name, nil is returned.
+func Get(name string) *MIB {
+	return DefaultRegistry.Get(name)
+}
+
+// GetAll returns all of the MIBs which have been registered with the default
+// registry, sorted by name. If no MIBs have been registered, an empty slice is
+// returned.
+func GetAll() []*MIB {
+	return DefaultRegistry.GetAll()
+}
+
+// Detect returns all MIBs in the default registry which are implemented by an
+// agent with the given identity.
+//
+// See Registry.Detect for details.
+func Detect(identity *core.AgentIdentity) []*MIB {
+	return DefaultRegistry.Detect(identity)
+}
+
+// Clear removes all data from the default MIB registry.
 //
 // Generally, this should not be used by a plugin implementation, however
 // it is useful for testing.
 func Clear() {
-	pluginMibs = map[string]*MIB{}
+	DefaultRegistry.Clear()
 }
@@ -1,18 +1,21 @@
 package mibs
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
 )
 
 func resetGlobalMibs() {
-	pluginMibs = map[string]*MIB{}
+	DefaultRegistry = NewRegistry()
 }
 
 func TestRegister(t *testing.T) {
 	defer resetGlobalMibs()
-	assert.Empty(t, pluginMibs)
+	assert.Empty(t, DefaultRegistry.mibs)
 
 	mib1 := &MIB{Name: "mib-1"}
 	mib2 := &MIB{Name: "mib-2"}
@@ -20,14 +23,14 @@ func TestRegister(t *testing.T) {
 	err := Register(mib1, mib2)
 	assert.NoError(t, err)
 
-	assert.Len(t, pluginMibs, 2)
-	assert.NotNil(t, pluginMibs["mib-1"])
-	assert.NotNil(t, pluginMibs["mib-2"])
+	assert.Len(t, DefaultRegistry.mibs, 2)
+	assert.NotNil(t, DefaultRegistry.mibs["mib-1"])
+	assert.NotNil(t, DefaultRegistry.mibs["mib-2"])
 }
 
 func TestRegister_NilMib(t *testing.T) {
 	defer resetGlobalMibs()
-	assert.Empty(t, pluginMibs)
+	assert.Empty(t, DefaultRegistry.mibs)
 
 	mib1 := &MIB{Name: "mib-1"}
 
@@ -35,13 +38,13 @@ func TestRegister_NilMib(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrNilMib, err)
 
-	assert.Len(t, pluginMibs, 1) // the first non-nil mib was added
-	assert.NotNil(t, pluginMibs["mib-1"])
+	assert.Len(t, DefaultRegistry.mibs, 1) // the first non-nil mib was added
+	assert.NotNil(t, DefaultRegistry.mibs["mib-1"])
 }
 
 func TestRegister_MibExists(t *testing.T) {
 	defer resetGlobalMibs()
-	assert.Empty(t, pluginMibs)
+	assert.Empty(t, DefaultRegistry.mibs)
 
 	mib1 := &MIB{Name: "mib-1"}
 	mib2 := &MIB{Name: "mib-1"} // same name
@@ -50,18 +53,142 @@ func TestRegister_MibExists(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrMibExists, err)
 
-	assert.Len(t, pluginMibs, 1) // the first non-duplicate mib was added
-	assert.NotNil(t, pluginMibs["mib-1"])
+	assert.Len(t, DefaultRegistry.mibs, 1) // the first non-duplicate mib was added
+	assert.NotNil(t, DefaultRegistry.mibs["mib-1"])
+}
+
+func TestRegister_Extends(t *testing.T) {
+	defer resetGlobalMibs()
+
+	base := &MIB{
+		Name:    "base-mib",
+		RootOid: "1.3.6.1.2.1.33",
+		Devices: []*SnmpDevice{
+			{OID: "1.3.6.1.2.1.33.1.1.1.0", Info: "manufacturer", Type: "string", Handler: "read-only", Output: "string"},
+			{OID: "1.3.6.1.2.1.33.1.1.2.0", Info: "model", Type: "string", Handler: "read-only", Output: "string"},
+			{OID: "1.3.6.1.2.1.33.1.1.3.0", Info: "software version", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+	vendor := &MIB{
+		Name:    "vendor-mib",
+		RootOid: "1.3.6.1.4.1.534",
+		Extends: []string{"base-mib"},
+		Devices: []*SnmpDevice{
+			{OID: "1.3.6.1.4.1.534.1.1.1.0", Info: "vendor device", Type: "string", Handler: "read-only", Output: "string"},
+			{OID: "1.3.6.1.2.1.33.1.1.1.0", Info: "vendor manufacturer", Type: "string", Handler: "read-only", Output: "string"},
+		},
+		Overrides: []*SnmpDevice{
+			{OID: "1.3.6.1.2.1.33.1.1.2.0", Alias: "ups-model"},
+		},
+		Removes: []string{"1.3.6.1.2.1.33.1.1.3.0"},
+	}
+
+	err := Register(base, vendor)
+	assert.NoError(t, err)
+
+	devices := vendor.ResolvedDevices()
+	assert.Len(t, devices, 3)
+	assert.Equal(t, "vendor manufacturer", devices[0].Info)
+	assert.Equal(t, "model", devices[1].Info)
+	assert.Equal(t, "ups-model", devices[1].Alias)
+	assert.Equal(t, "vendor device", devices[2].Info)
+
+	assert.Equal(t, []string{"1.3.6.1.4.1.534", "1.3.6.1.2.1.33"}, vendor.Roots())
+
+	// The base MIB is unaffected.
+	assert.Len(t, base.ResolvedDevices(), 3)
+	assert.Equal(t, "", base.Devices[1].Alias)
+}
+
+func TestRegister_Extends_RemoveRedefine(t *testing.T) {
+	defer resetGlobalMibs()
+
+	base := &MIB{
+		Name:    "base-mib",
+		RootOid: "1.3.6.1.2.1.33",
+		Devices: []*SnmpDevice{
+			{OID: "1.3.6.1.2.1.33.1.1.1.0", Info: "manufacturer", Type: "string", Handler: "read-only", Output: "string"},
+			{OID: "1.3.6.1.2.1.33.1.1.2.0", Info: "model", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+	vendor := &MIB{
+		Name:    "vendor-mib",
+		Extends: []string{"base-mib"},
+		Removes: []string{"1.3.6.1.2.1.33.1.1.1.0"},
+		Devices: []*SnmpDevice{
+			{OID: "1.3.6.1.2.1.33.1.1.1.0", Info: "vendor manufacturer", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+
+	err := Register(base, vendor)
+	assert.NoError(t, err)
+
+	// The redefined device is only included once, in its original position.
+	devices := vendor.ResolvedDevices()
+	assert.Len(t, devices, 2)
+	assert.Equal(t, "vendor manufacturer", devices[0].Info)
+	assert.Equal(t, "model", devices[1].Info)
+
+	loaded, err := vendor.LoadDevices(&core.SnmpTargetConfiguration{Agent: "localhost"}, map[string]struct{}{
+		"1.3.6.1.2.1.33.1.1.1.0": {},
+		"1.3.6.1.2.1.33.1.1.2.0": {},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+}
+
+func TestRegister_ExtendsUnknown(t *testing.T) {
+	defer resetGlobalMibs()
+
+	err := Register(&MIB{Name: "vendor-mib", Extends: []string{"base-mib"}})
+	assert.Error(t, err)
+	assert.Empty(t, DefaultRegistry.mibs)
+}
+
+func TestRegister_OverrideUnknown(t *testing.T) {
+	defer resetGlobalMibs()
+
+	err := Register(&MIB{
+		Name:      "mib-1",
+		Overrides: []*SnmpDevice{{OID: "1.2.3.4", Info: "unknown"}},
+	})
+	assert.Error(t, err)
+	assert.Empty(t, DefaultRegistry.mibs)
+}
+
+func TestRegister_RemoveUnknown(t *testing.T) {
+	defer resetGlobalMibs()
+
+	err := Register(&MIB{
+		Name:    "mib-1",
+		Removes: []string{"1.2.3.4"},
+	})
+	assert.Error(t, err)
+	assert.Empty(t, DefaultRegistry.mibs)
+}
+
+func TestRegister_InvalidMib(t *testing.T) {
+	defer resetGlobalMibs()
+
+	err := Register(&MIB{
+		Name: "mib-1",
+		Devices: []*SnmpDevice{
+			{OID: "not-an-oid", Handler: "read-only", Output: "string"},
+		},
+	})
+	assert.Error(t, err)
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Empty(t, DefaultRegistry.mibs)
 }
 
 func TestGet_Exists(t *testing.T) {
 	defer resetGlobalMibs()
 
-	pluginMibs["test-mib"] = &MIB{Name: "test-mib"}
+	DefaultRegistry.mibs["test-mib"] = &MIB{Name: "test-mib"}
 
 	mib := Get("test-mib")
 	assert.NotNil(t, mib)
-	assert.Equal(t, pluginMibs["test-mib"], mib)
+	assert.Equal(t, DefaultRegistry.mibs["test-mib"], mib)
 }
 
 func TestGet_NotExists(t *testing.T) {
@@ -78,9 +205,9 @@ func TestGetAll(t *testing.T) {
 	mib2 := &MIB{Name: "mib-2"}
 	mib3 := &MIB{Name: "mib-3"}
 
-	pluginMibs["mib-1"] = mib1
-	pluginMibs["mib-2"] = mib2
-	pluginMibs["mib-3"] = mib3
+	DefaultRegistry.mibs["mib-1"] = mib1
+	DefaultRegistry.mibs["mib-2"] = mib2
+	DefaultRegistry.mibs["mib-3"] = mib3
 
 	mibs := GetAll()
 	assert.Len(t, mibs, 3)
@@ -98,21 +225,252 @@ func TestGetAll_Empty(t *testing.T) {
 	assert.Empty(t, mibs)
 }
 
+func TestDetect(t *testing.T) {
+	defer resetGlobalMibs()
+
+	DefaultRegistry.mibs["mib-2"] = &MIB{Name: "mib-2", CapabilityOids: []string{"1.3.6.1.2.1.33"}}
+	DefaultRegistry.mibs["mib-1"] = &MIB{Name: "mib-1", EnterpriseOids: []string{"1.3.6.1.4.1.534"}}
+	DefaultRegistry.mibs["mib-3"] = &MIB{Name: "mib-3", EnterpriseOids: []string{"1.3.6.1.4.1.318"}}
+
+	mibs := Detect(&core.AgentIdentity{
+		SysObjectID:  "1.3.6.1.4.1.534.1",
+		Capabilities: []string{"1.3.6.1.2.1.33"},
+	})
+	assert.Len(t, mibs, 2)
+	assert.Equal(t, "mib-1", mibs[0].Name)
+	assert.Equal(t, "mib-2", mibs[1].Name)
+}
+
+func TestDetect_NoMatch(t *testing.T) {
+	defer resetGlobalMibs()
+
+	DefaultRegistry.mibs["mib-1"] = &MIB{Name: "mib-1", EnterpriseOids: []string{"1.3.6.1.4.1.534"}}
+
+	mibs := Detect(&core.AgentIdentity{SysObjectID: "1.3.6.1.4.1.318.1"})
+	assert.Empty(t, mibs)
+}
+
 func TestClear(t *testing.T) {
 	defer resetGlobalMibs()
 
-	pluginMibs["foo"] = &MIB{}
-	pluginMibs["boo"] = &MIB{}
+	DefaultRegistry.mibs["foo"] = &MIB{}
+	DefaultRegistry.mibs["boo"] = &MIB{}
 
-	assert.Len(t, pluginMibs, 2)
+	assert.Len(t, DefaultRegistry.mibs, 2)
 	Clear()
-	assert.Len(t, pluginMibs, 0)
+	assert.Len(t, DefaultRegistry.mibs, 0)
 }
 
 func TestClear_Empty(t *testing.T) {
 	defer resetGlobalMibs()
 
-	assert.Len(t, pluginMibs, 0)
+	assert.Len(t, DefaultRegistry.mibs, 0)
 	Clear()
-	assert.Len(t, pluginMibs, 0)
+	assert.Len(t, DefaultRegistry.mibs, 0)
+}
+
+func TestRegistry_Isolated(t *testing.T) {
+	r1 := NewRegistry()
+	r2 := NewRegistry()
+
+	assert.NoError(t, r1.Register(&MIB{Name: "mib-1"}))
+	assert.NoError(t, r2.Register(&MIB{Name: "mib-1"}))
+
+	assert.NotNil(t, r1.Get("mib-1"))
+	assert.NotNil(t, r2.Get("mib-1"))
+	assert.Nil(t, Get("mib-1"))
+}
+
+func TestRegistry_GetAll_Sorted(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(&MIB{Name: "mib-c"}, &MIB{Name: "mib-a"}, &MIB{Name: "mib-b"}))
+
+	mibs := r.GetAll()
+	assert.Len(t, mibs, 3)
+	assert.Equal(t, "mib-a", mibs[0].Name)
+	assert.Equal(t, "mib-b", mibs[1].Name)
+	assert.Equal(t, "mib-c", mibs[2].Name)
+}
+
+func TestRegistry_Unregister(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(&MIB{Name: "mib-1"}, &MIB{Name: "mib-2"}))
+
+	err := r.Unregister("mib-1")
+	assert.NoError(t, err)
+	assert.Nil(t, r.Get("mib-1"))
+	assert.NotNil(t, r.Get("mib-2"))
+}
+
+func TestRegistry_Unregister_NotFound(t *testing.T) {
+	r := NewRegistry()
+
+	err := r.Unregister("mib-1")
+	assert.Error(t, err)
+	assert.Equal(t, ErrMibNotFound, err)
+}
+
+func TestRegistry_Replace(t *testing.T) {
+	r := NewRegistry()
+	assert.NoError(t, r.Register(&MIB{Name: "mib-1", RootOid: "1.2.3"}))
+
+	err := r.Replace(&MIB{Name: "mib-1", RootOid: "4.5.6"})
+	assert.NoError(t, err)
+	assert.Equal(t, "4.5.6", r.Get("mib-1").RootOid)
+
+	// Replacing a MIB which is not yet registered registers it.
+	err = r.Replace(&MIB{Name: "mib-2"})
+	assert.NoError(t, err)
+	assert.NotNil(t, r.Get("mib-2"))
+}
+
+func TestRegistry_Replace_Nil(t *testing.T) {
+	r := NewRegistry()
+
+	err := r.Replace(nil)
+	assert.Error(t, err)
+	assert.Equal(t, ErrNilMib, err)
+}
+
+func TestRegistry_Replace_ResolvesDependents(t *testing.T) {
+	r := NewRegistry()
+
+	base := &MIB{
+		Name: "base-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.1", Info: "device 1", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+	vendor := &MIB{
+		Name:    "vendor-mib",
+		Extends: []string{"base-mib"},
+	}
+	assert.NoError(t, r.Register(base, vendor))
+	assert.Len(t, vendor.ResolvedDevices(), 1)
+
+	err := r.Replace(&MIB{
+		Name: "base-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.1", Info: "device 1", Type: "string", Handler: "read-only", Output: "string"},
+			{OID: "1.2.3.2", Info: "device 2", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, vendor.ResolvedDevices(), 2)
+}
+
+func TestRegistry_Replace_Transitive(t *testing.T) {
+	r := NewRegistry()
+
+	base := &MIB{
+		Name: "base-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.1", Info: "device 1", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+	vendor := &MIB{Name: "vendor-mib", Extends: []string{"base-mib"}}
+	// The model MIB extends both, so it must be resolved after the vendor MIB.
+	model := &MIB{Name: "a-model-mib", Extends: []string{"vendor-mib", "base-mib"}}
+	assert.NoError(t, r.Register(base, vendor, model))
+
+	err := r.Replace(&MIB{
+		Name: "base-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.2", Info: "device 2", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, vendor.ResolvedDevices(), 1)
+	assert.Equal(t, "1.2.3.2", vendor.ResolvedDevices()[0].OID)
+	assert.Len(t, model.ResolvedDevices(), 1)
+	assert.Equal(t, "1.2.3.2", model.ResolvedDevices()[0].OID)
+}
+
+func TestRegistry_Replace_DependentFails(t *testing.T) {
+	r := NewRegistry()
+
+	base := &MIB{
+		Name: "base-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.1", Info: "device 1", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+	vendor := &MIB{
+		Name:    "vendor-mib",
+		Extends: []string{"base-mib"},
+		Removes: []string{"1.2.3.1"},
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.2", Info: "device 2", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+	assert.NoError(t, r.Register(base, vendor))
+
+	// The vendor MIB removes a device the new base MIB no longer defines, so the
+	// replacement fails and nothing changes.
+	err := r.Replace(&MIB{
+		Name: "base-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.3", Info: "device 3", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	})
+	assert.EqualError(t, err, "MIB vendor-mib removes unknown device 1.2.3.1")
+	assert.True(t, base == r.Get("base-mib"))
+	assert.Len(t, vendor.ResolvedDevices(), 1)
+	assert.Equal(t, "1.2.3.2", vendor.ResolvedDevices()[0].OID)
+}
+
+func TestRegistry_Replace_ConcurrentRead(t *testing.T) {
+	r := NewRegistry()
+
+	base := &MIB{
+		Name: "base-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.1", Info: "device 1", Type: "string", Handler: "read-only", Output: "string"},
+		},
+	}
+	vendor := &MIB{Name: "vendor-mib", Extends: []string{"base-mib"}}
+	assert.NoError(t, r.Register(base, vendor))
+
+	// Readers always see a complete device set while the MIB is replaced.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				assert.Len(t, vendor.ResolvedDevices(), 1)
+				assert.Len(t, vendor.Roots(), 1)
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, r.Replace(&MIB{
+			Name: "base-mib",
+			Devices: []*SnmpDevice{
+				{OID: fmt.Sprintf("1.2.3.%d", i), Info: "device", Type: "string", Handler: "read-only", Output: "string"},
+			},
+		}))
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestRegistry_ConcurrentRegister(t *testing.T) {
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, r.Register(&MIB{Name: fmt.Sprintf("mib-%d", i)}))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, r.GetAll(), 50)
 }
@@ -0,0 +1,268 @@
+package mibs
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/iancoleman/strcase"
+	log "github.com/sirupsen/logrus"
+	"github.com/soniah/gosnmp"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/handlers"
+)
+
+// keyPattern matches a well-formed computed device key. Keys start with a letter, so
+// they cannot be mistaken for OIDs.
+var keyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// ComputedDevice models a virtual device whose reading is not exposed by the agent
+// directly, but is computed from the values of one or more OIDs, e.g. the apparent
+// power of an outlet from its voltage and current.
+//
+// The values of all inputs are fetched in a single GET request for each reading,
+// so a computed device may have at most gosnmp.MaxOids inputs. A computed device
+// is only loaded if the agent supports all of its inputs.
+type ComputedDevice struct {
+	// Required fields
+	Key    string
+	Info   string
+	Type   string
+	Output string
+	Inputs []string
+
+	// Expression is an arithmetic expression which computes the device's value
+	// from the values of its inputs (see core.ParseExpression), e.g. "$1 * $2".
+	// Exactly one of Expression or Func must be set.
+	Expression string
+
+	// Func computes the device's value from the values of its inputs, for
+	// computations which cannot be written as an expression.
+	Func core.ComputeFunc
+
+	// Optional fields
+	Tags       []*sdk.Tag
+	Data       map[string]interface{}
+	Context    map[string]string
+	Alias      string
+	Transforms []sdk.Transformer
+}
+
+// String returns a human-readable string, useful for identifying the
+// device in logs.
+func (device *ComputedDevice) String() string {
+	return fmt.Sprintf("[ComputedDevice %s: %s]", device.Key, device.Info)
+}
+
+// compute gets the function which computes the device's value.
+func (device *ComputedDevice) compute() (core.ComputeFunc, error) {
+	if device.Func != nil {
+		return device.Func, nil
+	}
+	return core.ParseExpression(device.Expression, len(device.Inputs))
+}
+
+// ToDevice converts the ComputedDevice to a Synse SDK Device, which is read by the
+// computed device handler (handlers.Computed).
+func (device *ComputedDevice) ToDevice() (*sdk.Device, error) {
+	log.WithFields(log.Fields{
+		"key":  device.Key,
+		"info": device.Info,
+	}).Debug("[snmp] creating synse device from MIB computed device")
+
+	compute, err := device.compute()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create synse device: %v", err)
+	}
+	inputs := make([]string, len(device.Inputs))
+	for i, oid := range device.Inputs {
+		inputs[i] = core.NormalizeOid(oid)
+	}
+
+	// Construct the device data. The key takes the place of the OID when
+	// generating the device ID.
+	data := map[string]interface{}{}
+	for k, v := range device.Data {
+		data[k] = v
+	}
+	data["key"] = device.Key
+	data["inputs"] = inputs
+	data["compute"] = compute
+
+	// Construct the device context.
+	context := map[string]string{}
+	for k, v := range device.Context {
+		context[k] = v
+	}
+	context["key"] = device.Key
+
+	tags := []*sdk.Tag{
+		core.TagOrPanic("protocol/snmp"),
+		core.TagOrPanic(fmt.Sprintf("snmp/computed:%s", device.Key)),
+		core.TagOrPanic(fmt.Sprintf("snmp/name:%s", strcase.ToLowerCamel(device.Info))),
+	}
+	tags = append(tags, device.Tags...)
+
+	if o := output.Get(device.Output); o == nil {
+		return nil, fmt.Errorf("unable to create synse device: output '%s' not registered", device.Output)
+	}
+
+	return &sdk.Device{
+		Type:       device.Type,
+		Info:       device.Info,
+		Handler:    handlers.Computed.Name,
+		Alias:      device.Alias,
+		Output:     device.Output,
+		Transforms: device.Transforms,
+		Tags:       tags,
+		Data:       data,
+		Context:    context,
+	}, nil
+}
+
+// ResolvedComputed gets the full set of computed devices for the MIB. For MIBs which
+// extend other MIBs, this is the set computed at registration time, with the MIB's
+// own computed devices replacing inherited ones with the same key. Otherwise, it is
+// the MIB's Computed devices.
+func (mib *MIB) ResolvedComputed() []*ComputedDevice {
+	if res := mib.resolved(); res != nil && res.computed != nil {
+		return res.computed
+	}
+	return mib.Computed
+}
+
+// InputOids gets the OIDs of the inputs of the MIB's computed devices, which need
+// to be discovered along with the MIB's devices.
+func (mib *MIB) InputOids() []string {
+	var oids []string
+	seen := map[string]struct{}{}
+	for _, d := range mib.ResolvedComputed() {
+		for _, oid := range d.Inputs {
+			oid = core.NormalizeOid(oid)
+			if _, exists := seen[oid]; !exists {
+				seen[oid] = struct{}{}
+				oids = append(oids, oid)
+			}
+		}
+	}
+	return oids
+}
+
+// loadComputed loads Synse devices from the computed devices defined in the MIB
+// whose inputs are all in the set of supported OIDs.
+func (mib *MIB) loadComputed(cfg *core.SnmpTargetConfiguration, supported map[string]struct{}) ([]*sdk.Device, error) {
+	var devices []*sdk.Device
+	for _, d := range mib.ResolvedComputed() {
+		var missing []string
+		for _, oid := range d.Inputs {
+			if _, exists := supported[core.NormalizeOid(oid)]; !exists {
+				missing = append(missing, oid)
+			}
+		}
+		if len(missing) != 0 {
+			log.WithFields(log.Fields{
+				"key":     d.Key,
+				"missing": missing,
+				"agent":   cfg.Agent,
+			}).Debug("[snmp] computed device inputs not supported by agent; will not load")
+			continue
+		}
+
+		device, err := d.ToDevice()
+		if err != nil {
+			return nil, err
+		}
+		device.Data["mib"] = mib.Name
+		device.Data["agent"] = cfg.Agent
+		device.Data["target_cfg"] = cfg
+
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// resolveComputed computes the computed device set for a MIB which extends other
+// MIBs. The computed devices of each extended MIB are inherited, in order, with the
+// MIB's own computed devices added on top. If the MIB extends no other MIBs, nil is
+// returned.
+func (mib *MIB) resolveComputed(parents []*MIB) []*ComputedDevice {
+	if len(parents) == 0 {
+		return nil
+	}
+
+	var order []string
+	devices := map[string]*ComputedDevice{}
+	add := func(d *ComputedDevice) {
+		if _, exists := devices[d.Key]; !exists {
+			order = append(order, d.Key)
+		}
+		devices[d.Key] = d
+	}
+	for _, parent := range parents {
+		for _, d := range parent.ResolvedComputed() {
+			add(d)
+		}
+	}
+	for _, d := range mib.Computed {
+		add(d)
+	}
+
+	resolved := make([]*ComputedDevice, 0, len(order))
+	for _, key := range order {
+		resolved = append(resolved, devices[key])
+	}
+	return resolved
+}
+
+// validateComputed checks each computed device of the MIB, returning the problems
+// found.
+func (mib *MIB) validateComputed() []string {
+	var problems []string
+	seen := map[string]struct{}{}
+
+	for _, d := range mib.ResolvedComputed() {
+		if d == nil {
+			problems = append(problems, "nil computed device")
+			continue
+		}
+
+		if !keyPattern.MatchString(d.Key) {
+			problems = append(problems, fmt.Sprintf("computed device %q: malformed key", d.Key))
+		}
+		if _, exists := seen[d.Key]; exists {
+			problems = append(problems, fmt.Sprintf("computed device %s: duplicate key", d.Key))
+		}
+		seen[d.Key] = struct{}{}
+
+		if d.Type == "" {
+			problems = append(problems, fmt.Sprintf("computed device %s: no type specified", d.Key))
+		}
+		if output.Get(d.Output) == nil {
+			problems = append(problems, fmt.Sprintf("computed device %s: output %q not registered", d.Key, d.Output))
+		}
+
+		if len(d.Inputs) == 0 {
+			problems = append(problems, fmt.Sprintf("computed device %s: no inputs specified", d.Key))
+		} else if len(d.Inputs) > gosnmp.MaxOids {
+			problems = append(problems, fmt.Sprintf("computed device %s: more than %d inputs", d.Key, gosnmp.MaxOids))
+		}
+		for _, oid := range d.Inputs {
+			if !oidPattern.MatchString(oid) {
+				problems = append(problems, fmt.Sprintf("computed device %s: malformed input OID %q", d.Key, oid))
+			}
+		}
+
+		switch {
+		case d.Expression == "" && d.Func == nil:
+			problems = append(problems, fmt.Sprintf("computed device %s: no expression or function specified", d.Key))
+		case d.Expression != "" && d.Func != nil:
+			problems = append(problems, fmt.Sprintf("computed device %s: both expression and function specified", d.Key))
+		case d.Expression != "":
+			if _, err := core.ParseExpression(d.Expression, len(d.Inputs)); err != nil {
+				problems = append(problems, fmt.Sprintf("computed device %s: %v", d.Key, err))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,177 @@
+package mibs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+func TestComputedDevice_String(t *testing.T) {
+	d := ComputedDevice{Key: "apparent-power", Info: "apparent power"}
+	assert.Equal(t, "[ComputedDevice apparent-power: apparent power]", d.String())
+}
+
+func TestComputedDevice_ToDevice(t *testing.T) {
+	d := ComputedDevice{
+		Key:        "apparent-power",
+		Info:       "apparent power",
+		Type:       "power",
+		Output:     "watt",
+		Inputs:     []string{".1.2.3.1", "1.2.3.2"},
+		Expression: "$1 * $2",
+		Tags: []*sdk.Tag{
+			core.TagOrPanic("vaporio/test:device"),
+		},
+		Data: map[string]interface{}{
+			"foo": "bar",
+		},
+		Context: map[string]string{
+			"abc": "123",
+		},
+		Alias: "test-device",
+	}
+
+	dev, err := d.ToDevice()
+	assert.NoError(t, err)
+	assert.NotNil(t, dev)
+
+	assert.Equal(t, "power", dev.Type)
+	assert.Equal(t, "apparent power", dev.Info)
+	assert.Equal(t, "computed", dev.Handler)
+	assert.Equal(t, "test-device", dev.Alias)
+	assert.Equal(t, "watt", dev.Output)
+	assert.Equal(t, "bar", dev.Data["foo"])
+	assert.Equal(t, "apparent-power", dev.Data["key"])
+	assert.Equal(t, []string{"1.2.3.1", "1.2.3.2"}, dev.Data["inputs"])
+	assert.NotContains(t, dev.Data, "oid")
+	assert.Equal(t, map[string]string{
+		"abc": "123",
+		"key": "apparent-power",
+	}, dev.Context)
+
+	compute, ok := dev.Data["compute"].(core.ComputeFunc)
+	assert.True(t, ok)
+	value, err := compute([]interface{}{230, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(460), value)
+
+	assert.Len(t, dev.Tags, 4)
+	tagEquals(t, dev.Tags[0], &sdk.Tag{Namespace: "protocol", Annotation: "", Label: "snmp"})
+	tagEquals(t, dev.Tags[1], &sdk.Tag{Namespace: "snmp", Annotation: "computed", Label: "apparent-power"})
+	tagEquals(t, dev.Tags[2], &sdk.Tag{Namespace: "snmp", Annotation: "name", Label: "apparentPower"})
+	tagEquals(t, dev.Tags[3], &sdk.Tag{Namespace: "vaporio", Annotation: "test", Label: "device"})
+}
+
+func TestComputedDevice_ToDevice_Func(t *testing.T) {
+	d := ComputedDevice{
+		Key:    "charge",
+		Info:   "battery charge",
+		Type:   "battery",
+		Output: "percentage",
+		Inputs: []string{"1.2.3.1"},
+		Func: func(values []interface{}) (interface{}, error) {
+			return "computed", nil
+		},
+	}
+
+	dev, err := d.ToDevice()
+	assert.NoError(t, err)
+
+	value, err := dev.Data["compute"].(core.ComputeFunc)(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "computed", value)
+}
+
+func TestComputedDevice_ToDevice_Errors(t *testing.T) {
+	d := ComputedDevice{
+		Key:        "charge",
+		Info:       "battery charge",
+		Type:       "battery",
+		Output:     "percentage",
+		Inputs:     []string{"1.2.3.1"},
+		Expression: "$1 / $2",
+	}
+	_, err := d.ToDevice()
+	assert.Error(t, err)
+
+	d.Expression = "$1"
+	d.Output = "unknown"
+	_, err = d.ToDevice()
+	assert.Error(t, err)
+}
+
+func TestMIB_InputOids(t *testing.T) {
+	mib := MIB{
+		Computed: []*ComputedDevice{
+			{Key: "a", Inputs: []string{"1.2.3.1", ".1.2.3.2"}},
+			{Key: "b", Inputs: []string{"1.2.3.2", "1.2.4.1"}},
+		},
+	}
+	assert.Equal(t, []string{"1.2.3.1", "1.2.3.2", "1.2.4.1"}, mib.InputOids())
+	assert.Equal(t, []string{"1.2.3", "1.2.4"}, mib.Roots())
+}
+
+func TestMIB_LoadDevices_Computed(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.1", Info: "voltage", Type: "voltage", Handler: "read-only", Output: "voltage"},
+		},
+		Computed: []*ComputedDevice{
+			{Key: "power", Info: "power", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1", "1.2.3.2"}, Expression: "$1 * $2"},
+			{Key: "load", Info: "load", Type: "current", Output: "electric-current", Inputs: []string{"1.2.3.3", "1.2.3.4"}, Expression: "$1 + $2"},
+		},
+	}
+
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:     "test-mib",
+		Version: "v2",
+		Agent:   "localhost",
+	}
+	devices, err := mib.LoadDevices(cfg, map[string]struct{}{
+		"1.2.3.1": {},
+		"1.2.3.2": {},
+		"1.2.3.3": {},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	assert.Equal(t, "1.2.3.1", devices[0].Data["oid"])
+	assert.Equal(t, "power", devices[1].Data["key"])
+	assert.Equal(t, "test-mib", devices[1].Data["mib"])
+	assert.Equal(t, "localhost", devices[1].Data["agent"])
+	assert.Equal(t, cfg, devices[1].Data["target_cfg"])
+}
+
+func TestRegister_ExtendsComputed(t *testing.T) {
+	registry := NewRegistry()
+
+	base := &MIB{
+		Name:    "base-mib",
+		RootOid: "1.2.3",
+		Computed: []*ComputedDevice{
+			{Key: "power", Info: "power", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1", "1.2.3.2"}, Expression: "$1 * $2"},
+			{Key: "load", Info: "load", Type: "current", Output: "electric-current", Inputs: []string{"1.2.3.3"}, Expression: "$1"},
+		},
+	}
+	vendor := &MIB{
+		Name:    "vendor-mib",
+		Extends: []string{"base-mib"},
+		Computed: []*ComputedDevice{
+			{Key: "power", Info: "vendor power", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1", "1.2.3.2"}, Expression: "$1 * $2 * 0.9"},
+			{Key: "charge", Info: "charge", Type: "battery", Output: "percentage", Inputs: []string{"1.2.3.4", "1.2.3.5"}, Expression: "$1 / $2 * 100"},
+		},
+	}
+	assert.NoError(t, registry.Register(base, vendor))
+
+	computed := vendor.ResolvedComputed()
+	assert.Len(t, computed, 3)
+	assert.Equal(t, "vendor power", computed[0].Info)
+	assert.Equal(t, "load", computed[1].Info)
+	assert.Equal(t, "charge", computed[2].Info)
+
+	// The base MIB is unaffected.
+	assert.Len(t, base.ResolvedComputed(), 2)
+}
@@ -32,6 +32,70 @@ type SnmpDevice struct {
 	Alias        string
 	Transforms   []sdk.Transformer
 	WriteTimeout time.Duration
+
+	// SMIType is the SMI type the device's OID is declared as (e.g.
+	// core.SMIGauge32). It is checked against the type of the value the
+	// agent returns during discovery. If not set, the value is only expected
+	// to be numeric if the device's output has a unit.
+	SMIType string
+
+	// Required marks a device which every agent implementing the MIB must
+	// support. If the agent does not support it, discovery either fails or
+	// the target is marked as degraded, depending on the target configuration.
+	Required bool
+
+	// Condition is the condition under which the device is present on an
+	// agent. If set, the device is only loaded if the agent supports it and
+	// the condition is met.
+	Condition *Condition
+
+	// Readings are additional OIDs which the device reports readings for, along
+	// with the device's OID, e.g. the current and power of an outlet device whose
+	// OID is the outlet's state. The values of all OIDs are fetched in a single
+	// GET request. For devices whose OID is a pattern, the reading OIDs may use
+	// the same wildcard components, which are replaced by the components the
+	// device's OID matched.
+	Readings []*core.ReadingOid
+}
+
+// Condition is a condition on the value of an OID, e.g. a flag which indicates
+// whether an optional sensor is installed.
+type Condition struct {
+	// OID is the OID whose value is checked.
+	OID string
+
+	// Values are the values of the OID for which the condition is met. The
+	// values are compared by their string form, so e.g. both 1 and "1" match
+	// an Integer value of 1. Boolean values are compared as the SNMPv2-TC
+	// TruthValue they stand for, so true matches 1 and false matches 2.
+	Values []interface{}
+}
+
+// String returns a human-readable string, useful for identifying the
+// condition in logs.
+func (condition *Condition) String() string {
+	return fmt.Sprintf("[Condition %s in %v]", condition.OID, condition.Values)
+}
+
+// Matches checks whether the given value of the condition's OID meets the condition.
+func (condition *Condition) Matches(value interface{}) bool {
+	for _, v := range condition.Values {
+		if b, ok := v.(bool); ok {
+			v = truthValue(b)
+		}
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// truthValue gets the SNMPv2-TC TruthValue of a boolean: true(1) or false(2).
+func truthValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 2
 }
 
 // String returns a human-readable string, useful for identifying the
@@ -40,6 +104,16 @@ func (device *SnmpDevice) String() string {
 	return fmt.Sprintf("[SnmpDevice %s: %s]", device.OID, device.Info)
 }
 
+// OIDs gets the OIDs the device reports readings for: the device's OID, followed by
+// the OIDs of its additional Readings.
+func (device *SnmpDevice) OIDs() []string {
+	oids := []string{device.OID}
+	for _, r := range device.Readings {
+		oids = append(oids, core.NormalizeOid(r.OID))
+	}
+	return oids
+}
+
 // ToDevice converts the plugin-specific SnmpDevice to a Synse SDK Device.
 func (device *SnmpDevice) ToDevice() (*sdk.Device, error) {
 	log.WithFields(log.Fields{
@@ -55,6 +129,23 @@ func (device *SnmpDevice) ToDevice() (*sdk.Device, error) {
 	// (via MIB.LoadDevices)
 	data["oid"] = device.OID
 
+	// Additional readings are only set for devices which have them, so the
+	// device data of single reading devices is unchanged.
+	if len(device.Readings) != 0 {
+		readings := make([]*core.ReadingOid, len(device.Readings))
+		for i, r := range device.Readings {
+			if o := output.Get(r.Output); o == nil {
+				return nil, fmt.Errorf("unable to create synse device: output '%s' not registered", r.Output)
+			}
+			readings[i] = &core.ReadingOid{
+				OID:     core.NormalizeOid(r.OID),
+				Output:  r.Output,
+				Context: r.Context,
+			}
+		}
+		data["readings"] = readings
+	}
+
 	// Construct the device context.
 	context := map[string]string{}
 	for k, v := range device.Context {
@@ -93,3 +184,67 @@ func (device *SnmpDevice) ToDevice() (*sdk.Device, error) {
 		Context:      context,
 	}, nil
 }
+
+// override creates a copy of the device with the non-zero fields of the given
+// override applied. Data and Context are merged key by key, with the override
+// values taking precedence.
+func (device *SnmpDevice) override(o *SnmpDevice) *SnmpDevice {
+	d := *device
+
+	if o.Info != "" {
+		d.Info = o.Info
+	}
+	if o.Type != "" {
+		d.Type = o.Type
+	}
+	if o.Handler != "" {
+		d.Handler = o.Handler
+	}
+	if o.Output != "" {
+		d.Output = o.Output
+	}
+	if o.Alias != "" {
+		d.Alias = o.Alias
+	}
+	if o.Tags != nil {
+		d.Tags = o.Tags
+	}
+	if o.Transforms != nil {
+		d.Transforms = o.Transforms
+	}
+	if o.WriteTimeout != 0 {
+		d.WriteTimeout = o.WriteTimeout
+	}
+	if o.SMIType != "" {
+		d.SMIType = o.SMIType
+	}
+	if o.Required {
+		d.Required = o.Required
+	}
+	if o.Condition != nil {
+		d.Condition = o.Condition
+	}
+	if o.Readings != nil {
+		d.Readings = o.Readings
+	}
+
+	if o.Data != nil {
+		d.Data = map[string]interface{}{}
+		for k, v := range device.Data {
+			d.Data[k] = v
+		}
+		for k, v := range o.Data {
+			d.Data[k] = v
+		}
+	}
+	if o.Context != nil {
+		d.Context = map[string]string{}
+		for k, v := range device.Context {
+			d.Context[k] = v
+		}
+		for k, v := range o.Context {
+			d.Context[k] = v
+		}
+	}
+	return &d
+}
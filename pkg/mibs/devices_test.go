@@ -116,6 +116,42 @@ func TestSnmpDevice_ToDevice2(t *testing.T) {
 	tagEquals(t, dev.Tags[2], &sdk.Tag{Namespace: "snmp", Annotation: "name", Label: "infoWithSpaces"})
 }
 
+func TestSnmpDevice_OIDs(t *testing.T) {
+	d := SnmpDevice{OID: "1.2.3.4"}
+	assert.Equal(t, []string{"1.2.3.4"}, d.OIDs())
+
+	d.Readings = []*core.ReadingOid{
+		{OID: ".1.2.3.5", Output: "electric-current"},
+		{OID: "1.2.3.6", Output: "watt"},
+	}
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.5", "1.2.3.6"}, d.OIDs())
+}
+
+func TestSnmpDevice_ToDevice_Readings(t *testing.T) {
+	d := SnmpDevice{
+		OID:     "1.2.3.4",
+		Info:    "outlet",
+		Type:    "power",
+		Handler: "read-only",
+		Output:  "status",
+		Readings: []*core.ReadingOid{
+			{OID: ".1.2.3.5", Output: "electric-current"},
+			{OID: "1.2.3.6", Output: "watt", Context: map[string]string{"foo": "bar"}},
+		},
+	}
+
+	dev, err := d.ToDevice()
+	assert.NoError(t, err)
+	assert.Equal(t, []*core.ReadingOid{
+		{OID: "1.2.3.5", Output: "electric-current"},
+		{OID: "1.2.3.6", Output: "watt", Context: map[string]string{"foo": "bar"}},
+	}, dev.Data["readings"])
+
+	d.Readings[1].Output = "unknown"
+	_, err = d.ToDevice()
+	assert.Error(t, err)
+}
+
 func TestSnmpDevice_ToDevice_BadOutput(t *testing.T) {
 	d := SnmpDevice{
 		OID:     "1.2.3.4",
@@ -129,3 +165,82 @@ func TestSnmpDevice_ToDevice_BadOutput(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, dev)
 }
+
+func TestSnmpDevice_override(t *testing.T) {
+	d := &SnmpDevice{
+		OID:     "1.2.3.4",
+		Info:    "info",
+		Type:    "temperature",
+		Handler: "read-only",
+		Output:  "temperature",
+		Data: map[string]interface{}{
+			"foo": "bar",
+			"abc": "123",
+		},
+		Context: map[string]string{
+			"foo": "bar",
+		},
+	}
+
+	o := d.override(&SnmpDevice{
+		OID:   "1.2.3.4",
+		Info:  "new info",
+		Alias: "alias",
+		Data: map[string]interface{}{
+			"abc": "456",
+			"enum": map[interface{}]interface{}{
+				1: "on",
+			},
+		},
+		Transforms: []sdk.Transformer{
+			&sdk.ScaleTransformer{Factor: 0.1},
+		},
+		Required:  true,
+		Condition: &Condition{OID: "1.2.3.1", Values: []interface{}{1}},
+		Readings:  []*core.ReadingOid{{OID: "1.2.3.5", Output: "electric-current"}},
+	})
+
+	assert.Equal(t, "1.2.3.4", o.OID)
+	assert.Equal(t, "new info", o.Info)
+	assert.Equal(t, "alias", o.Alias)
+	assert.Equal(t, "temperature", o.Type)
+	assert.Equal(t, "read-only", o.Handler)
+	assert.Equal(t, "temperature", o.Output)
+	assert.Len(t, o.Transforms, 1)
+	assert.True(t, o.Required)
+	assert.Equal(t, "1.2.3.1", o.Condition.OID)
+	assert.Len(t, o.Readings, 1)
+	assert.Equal(t, map[string]interface{}{
+		"foo": "bar",
+		"abc": "456",
+		"enum": map[interface{}]interface{}{
+			1: "on",
+		},
+	}, o.Data)
+	assert.Equal(t, map[string]string{"foo": "bar"}, o.Context)
+
+	// The original device is not modified.
+	assert.Equal(t, "info", d.Info)
+	assert.Equal(t, "123", d.Data["abc"])
+}
+
+func TestCondition_Matches(t *testing.T) {
+	condition := &Condition{OID: "1.2.3.1", Values: []interface{}{1, "yes"}}
+
+	assert.True(t, condition.Matches(1))
+	assert.True(t, condition.Matches(int64(1)))
+	assert.True(t, condition.Matches("yes"))
+	assert.False(t, condition.Matches(2))
+	assert.False(t, condition.Matches("no"))
+	assert.False(t, condition.Matches(nil))
+}
+
+func TestCondition_Matches_TruthValue(t *testing.T) {
+	condition := &Condition{OID: "1.2.3.1", Values: []interface{}{true}}
+	assert.True(t, condition.Matches(1))
+	assert.False(t, condition.Matches(2))
+
+	condition = &Condition{OID: "1.2.3.1", Values: []interface{}{false}}
+	assert.True(t, condition.Matches(2))
+	assert.False(t, condition.Matches(1))
+}
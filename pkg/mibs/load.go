@@ -0,0 +1,201 @@
+package mibs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/config"
+	"gopkg.in/yaml.v2"
+)
+
+// mibDefinition is the declarative (YAML) form of a MIB (see LoadFile).
+type mibDefinition struct {
+	Name              string              `yaml:"name"`
+	RootOid           string              `yaml:"rootOid,omitempty"`
+	RootOids          []string            `yaml:"rootOids,omitempty"`
+	EnterpriseOids    []string            `yaml:"enterpriseOids,omitempty"`
+	CapabilityOids    []string            `yaml:"capabilityOids,omitempty"`
+	Extends           []string            `yaml:"extends,omitempty"`
+	Removes           []string            `yaml:"removes,omitempty"`
+	DiscoveryStrategy string              `yaml:"discoveryStrategy,omitempty"`
+	Columns           []string            `yaml:"columns,omitempty"`
+	Devices           []*deviceDefinition `yaml:"devices,omitempty"`
+	Overrides         []*deviceDefinition `yaml:"overrides,omitempty"`
+}
+
+// deviceDefinition is the declarative (YAML) form of an SnmpDevice.
+type deviceDefinition struct {
+	OID        string                    `yaml:"oid"`
+	Info       string                    `yaml:"info,omitempty"`
+	Type       string                    `yaml:"type,omitempty"`
+	Handler    string                    `yaml:"handler,omitempty"`
+	Output     string                    `yaml:"output,omitempty"`
+	Alias      string                    `yaml:"alias,omitempty"`
+	SMIType    string                    `yaml:"smiType,omitempty"`
+	Required   bool                      `yaml:"required,omitempty"`
+	Tags       []string                  `yaml:"tags,omitempty"`
+	Data       map[string]interface{}    `yaml:"data,omitempty"`
+	Context    map[string]string         `yaml:"context,omitempty"`
+	Transforms []*config.TransformConfig `yaml:"transforms,omitempty"`
+	Condition  *conditionDefinition      `yaml:"condition,omitempty"`
+}
+
+// conditionDefinition is the declarative (YAML) form of a Condition.
+type conditionDefinition struct {
+	OID    string        `yaml:"oid"`
+	Values []interface{} `yaml:"values"`
+}
+
+// device creates the SnmpDevice for the definition.
+func (def *deviceDefinition) device() (*SnmpDevice, error) {
+	device := &SnmpDevice{
+		OID:      def.OID,
+		Info:     def.Info,
+		Type:     def.Type,
+		Handler:  def.Handler,
+		Output:   def.Output,
+		Alias:    def.Alias,
+		SMIType:  def.SMIType,
+		Required: def.Required,
+		Data:     def.Data,
+		Context:  def.Context,
+	}
+	if def.Condition != nil {
+		device.Condition = &Condition{OID: def.Condition.OID, Values: def.Condition.Values}
+	}
+	for _, t := range def.Tags {
+		tag, err := sdk.NewTag(t)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: invalid tag %q: %v", def.OID, t, err)
+		}
+		device.Tags = append(device.Tags, tag)
+	}
+	for _, cfg := range def.Transforms {
+		transformer, err := sdk.NewTransformer(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: invalid transform: %v", def.OID, err)
+		}
+		device.Transforms = append(device.Transforms, transformer)
+	}
+	return device, nil
+}
+
+// LoadFile loads a MIB from a declarative (YAML) definition file. The definition
+// has the same fields as a MIB defined in code, in lower camel case (e.g. "rootOid",
+// "enterpriseOids"), except for computed devices and additional device readings,
+// which can only be defined in code. Device transforms are defined as in the Synse
+// device configuration.
+//
+// The MIB is not registered; see Registry.LoadDir.
+func LoadFile(path string) (*MIB, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var def mibDefinition
+	if err := yaml.UnmarshalStrict(contents, &def); err != nil {
+		return nil, fmt.Errorf("invalid MIB definition %s: %v", path, err)
+	}
+
+	mib := &MIB{
+		Name:              def.Name,
+		RootOid:           def.RootOid,
+		RootOids:          def.RootOids,
+		EnterpriseOids:    def.EnterpriseOids,
+		CapabilityOids:    def.CapabilityOids,
+		Extends:           def.Extends,
+		Removes:           def.Removes,
+		DiscoveryStrategy: def.DiscoveryStrategy,
+		Columns:           def.Columns,
+	}
+	for _, d := range def.Devices {
+		device, err := d.device()
+		if err != nil {
+			return nil, fmt.Errorf("invalid MIB definition %s: %v", path, err)
+		}
+		mib.Devices = append(mib.Devices, device)
+	}
+	for _, d := range def.Overrides {
+		device, err := d.device()
+		if err != nil {
+			return nil, fmt.Errorf("invalid MIB definition %s: %v", path, err)
+		}
+		mib.Overrides = append(mib.Overrides, device)
+	}
+	return mib, nil
+}
+
+// LoadDir loads the MIBs defined by the declarative definition files (*.yaml and
+// *.yml) in a directory (see LoadFile) and registers them with the registry. MIBs
+// which extend other MIBs in the directory are registered after the MIBs they
+// extend, so the files may be named in any order.
+func (r *Registry) LoadDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var pending []*MIB
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		mib, err := LoadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return err
+		}
+		pending = append(pending, mib)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Name < pending[j].Name
+	})
+
+	// Register the MIBs whose extended MIBs are all registered, until none are
+	// left. MIBs which extend MIBs that are not defined at all are registered
+	// right away, so registration reports the missing MIBs.
+	for len(pending) != 0 {
+		var next []*MIB
+		for _, mib := range pending {
+			if !r.extendsRegistered(mib, pending) {
+				next = append(next, mib)
+				continue
+			}
+			if err := r.Register(mib); err != nil {
+				return fmt.Errorf("failed to register MIB %s: %v", mib.Name, err)
+			}
+			log.WithFields(log.Fields{
+				"mib":     mib.Name,
+				"devices": len(mib.ResolvedDevices()),
+			}).Info("[snmp] loaded MIB definition")
+		}
+		// If a pass registers nothing, the remaining MIBs extend each other.
+		if len(next) == len(pending) {
+			return fmt.Errorf("failed to register MIB %s: circular extends", next[0].Name)
+		}
+		pending = next
+	}
+	return nil
+}
+
+// extendsRegistered checks whether each MIB the given MIB extends is either registered
+// or not among the pending MIBs (in which case registration reports it as missing).
+func (r *Registry) extendsRegistered(mib *MIB, pending []*MIB) bool {
+	for _, name := range mib.Extends {
+		if r.Get(name) != nil {
+			continue
+		}
+		for _, p := range pending {
+			if p.Name == name && p != mib {
+				return false
+			}
+		}
+	}
+	return true
+}
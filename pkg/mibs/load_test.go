@@ -0,0 +1,174 @@
+package mibs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeDefinitions writes MIB definition files to a temporary directory, returning
+// the directory and a function which removes it.
+func writeDefinitions(t *testing.T, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "snmp-mib-definitions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, func() { _ = os.RemoveAll(dir) }
+}
+
+const testDefinition = `
+name: test-mib
+rootOid: 1.2.3
+enterpriseOids:
+  - 1.3.6.1.4.1.9999
+devices:
+  - oid: 1.2.3.4
+    info: inlet temperature
+    type: temperature
+    handler: read-only
+    output: temperature
+    smiType: Integer32
+    required: true
+    tags:
+      - vendor/test
+    context:
+      sensor: inlet
+    transforms:
+      - scale: "0.1"
+  - oid: 1.2.3.5
+    info: door
+    type: status
+    handler: read-only
+    output: status
+    condition:
+      oid: 1.2.3.1
+      values: [true]
+    data:
+      enum:
+        1: open
+        2: closed
+`
+
+func TestLoadFile(t *testing.T) {
+	dir, cleanup := writeDefinitions(t, map[string]string{"test.yaml": testDefinition})
+	defer cleanup()
+
+	mib, err := LoadFile(filepath.Join(dir, "test.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "test-mib", mib.Name)
+	assert.Equal(t, []string{"1.2.3"}, mib.Roots())
+	assert.Equal(t, []string{"1.3.6.1.4.1.9999"}, mib.EnterpriseOids)
+	assert.Len(t, mib.Devices, 2)
+
+	device := mib.Devices[0]
+	assert.Equal(t, "1.2.3.4", device.OID)
+	assert.Equal(t, "inlet temperature", device.Info)
+	assert.Equal(t, "Integer32", device.SMIType)
+	assert.True(t, device.Required)
+	assert.Len(t, device.Tags, 1)
+	assert.Equal(t, "vendor/test", device.Tags[0].String())
+	assert.Equal(t, map[string]string{"sensor": "inlet"}, device.Context)
+	assert.Len(t, device.Transforms, 1)
+
+	assert.Equal(t, map[interface{}]interface{}{1: "open", 2: "closed"}, mib.Devices[1].Data["enum"])
+	assert.Nil(t, device.Condition)
+
+	condition := mib.Devices[1].Condition
+	assert.Equal(t, "1.2.3.1", condition.OID)
+	assert.True(t, condition.Matches(1))
+	assert.False(t, condition.Matches(2))
+}
+
+func TestLoadFile_UnknownField(t *testing.T) {
+	dir, cleanup := writeDefinitions(t, map[string]string{"test.yaml": "name: test-mib\nrootOids: [1.2.3]\ncomputed: []\n"})
+	defer cleanup()
+
+	_, err := LoadFile(filepath.Join(dir, "test.yaml"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid MIB definition")
+}
+
+func TestLoadFile_BadTransform(t *testing.T) {
+	dir, cleanup := writeDefinitions(t, map[string]string{"test.yaml": `
+name: test-mib
+devices:
+  - oid: 1.2.3.4
+    info: test
+    type: temperature
+    handler: read-only
+    output: temperature
+    transforms:
+      - scale: "not a number"
+`})
+	defer cleanup()
+
+	_, err := LoadFile(filepath.Join(dir, "test.yaml"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "device 1.2.3.4: invalid transform")
+}
+
+func TestRegistry_LoadDir(t *testing.T) {
+	dir, cleanup := writeDefinitions(t, map[string]string{
+		// The extending MIB sorts before the MIB it extends.
+		"a-extended.yml": `
+name: a-extended-mib
+extends: [test-mib]
+removes: [1.2.3.5]
+devices:
+  - oid: 1.2.3.6
+    info: outlet temperature
+    type: temperature
+    handler: read-only
+    output: temperature
+`,
+		"test.yaml":  testDefinition,
+		"README.txt": "not a MIB definition",
+	})
+	defer cleanup()
+
+	registry := NewRegistry()
+	assert.NoError(t, registry.LoadDir(dir))
+	assert.Len(t, registry.GetAll(), 2)
+
+	extended := registry.Get("a-extended-mib")
+	assert.NotNil(t, extended)
+	var oids []string
+	for _, d := range extended.ResolvedDevices() {
+		oids = append(oids, d.OID)
+	}
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.6"}, oids)
+}
+
+func TestRegistry_LoadDir_MissingExtends(t *testing.T) {
+	dir, cleanup := writeDefinitions(t, map[string]string{
+		"extended.yaml": "name: extended-mib\nextends: [other-mib]\n",
+	})
+	defer cleanup()
+
+	err := NewRegistry().LoadDir(dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to register MIB extended-mib")
+}
+
+func TestRegistry_LoadDir_CircularExtends(t *testing.T) {
+	dir, cleanup := writeDefinitions(t, map[string]string{
+		"a.yaml": "name: a-mib\nextends: [b-mib]\n",
+		"b.yaml": "name: b-mib\nextends: [a-mib]\n",
+	})
+	defer cleanup()
+
+	err := NewRegistry().LoadDir(dir)
+	assert.EqualError(t, err, "failed to register MIB a-mib: circular extends")
+}
+
+func TestRegistry_LoadDir_NotFound(t *testing.T) {
+	assert.Error(t, NewRegistry().LoadDir("/nonexistent/mibs"))
+}
@@ -1,7 +1,12 @@
 package mibs
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -16,6 +21,98 @@ type MIB struct {
 	Name    string
 	RootOid string
 	Devices []*SnmpDevice
+
+	// Computed are virtual devices whose readings are computed from the values
+	// of one or more OIDs, rather than read from a single OID.
+	Computed []*ComputedDevice
+
+	// RootOids are additional subtree roots for MIBs which span more than one
+	// subtree, e.g. a vendor enterprise subtree as well as a standard subtree.
+	// If neither RootOid nor RootOids are set, the roots are derived from the
+	// OIDs of the MIB's devices.
+	RootOids []string
+
+	// EnterpriseOids are sysObjectID prefixes which identify agents that
+	// implement the MIB. They are used when auto-detecting the MIBs for
+	// a target.
+	EnterpriseOids []string
+
+	// CapabilityOids are the sysORTable capability OIDs (sysORID) which an
+	// agent advertises when it implements the MIB. They are used when
+	// auto-detecting the MIBs for a target.
+	CapabilityOids []string
+
+	// Extends are the names of other registered MIBs which this MIB builds
+	// upon. The devices of each extended MIB are inherited, in order, with
+	// the MIB's own Devices added on top. An own device with the same OID
+	// as an inherited device replaces it.
+	Extends []string
+
+	// Overrides modify inherited (or own) devices, matched by OID. Any
+	// non-zero field of an override replaces the corresponding field of
+	// the device; Data and Context are merged key by key.
+	Overrides []*SnmpDevice
+
+	// Removes are the OIDs of inherited devices which should not be
+	// included in the MIB.
+	Removes []string
+
+	// DiscoveryStrategy is the strategy used to discover which of the MIB's
+	// devices an agent supports: core.DiscoveryWalk (the default) walks the
+	// MIB's roots, and core.DiscoveryProbe gets the device OIDs directly and
+	// probes the MIB's Columns. A target may override the strategy.
+	DiscoveryStrategy string
+
+	// Columns are the OIDs of table columns which are probed with GETNEXT
+	// when using the probe discovery strategy. Every instance of a column
+	// found on the agent is considered supported.
+	Columns []string
+
+	// resolution holds the *resolution for MIBs which extend other MIBs. It
+	// is computed when the MIB is registered, and swapped as a whole when a
+	// MIB it extends is replaced, so it is safe to read while in use.
+	resolution atomic.Value
+}
+
+// resolution is the device set of a MIB which extends other MIBs, or which defines
+// overrides or removals (see MIB.resolve).
+type resolution struct {
+	// devices is the resolved device set.
+	devices []*SnmpDevice
+
+	// computed is the resolved computed device set, if the MIB extends
+	// other MIBs.
+	computed []*ComputedDevice
+
+	// inheritedRoots are the root OIDs of the MIBs the MIB extends.
+	inheritedRoots []string
+}
+
+// resolved gets the MIB's resolution, or nil if it has none.
+func (mib *MIB) resolved() *resolution {
+	res, _ := mib.resolution.Load().(*resolution)
+	return res
+}
+
+// staged gets a copy of the MIB with the given resolution, so that the resolution can
+// be validated, and used to resolve the MIBs which extend it, before it is applied.
+func (mib *MIB) staged(res *resolution) *MIB {
+	staged := &MIB{
+		Name:              mib.Name,
+		RootOid:           mib.RootOid,
+		RootOids:          mib.RootOids,
+		EnterpriseOids:    mib.EnterpriseOids,
+		CapabilityOids:    mib.CapabilityOids,
+		Devices:           mib.Devices,
+		Computed:          mib.Computed,
+		Extends:           mib.Extends,
+		Overrides:         mib.Overrides,
+		Removes:           mib.Removes,
+		DiscoveryStrategy: mib.DiscoveryStrategy,
+		Columns:           mib.Columns,
+	}
+	staged.resolution.Store(res)
+	return staged
 }
 
 // NewMIB creates a new MIB with the specified devices.
@@ -30,22 +127,161 @@ func NewMIB(name string, rootOid string, devices ...*SnmpDevice) *MIB {
 // String returns a human-readable string, useful for identifying the
 // MIB in logs.
 func (mib *MIB) String() string {
-	return fmt.Sprintf("[MIB %s (%s)]", mib.Name, mib.RootOid)
+	return fmt.Sprintf("[MIB %s (%s)]", mib.Name, strings.Join(mib.Roots(), ", "))
+}
+
+// Hash gets a hash of the parts of the MIB definition which affect device discovery:
+// its name, roots, device (and reading) OIDs, computed device inputs, discovery
+// strategy and table columns. It changes if any of them change, so it can be used to
+// tell whether a previous discovery result for the MIB is still valid.
+func (mib *MIB) Hash() string {
+	var oids []string
+	for _, d := range mib.ResolvedDevices() {
+		oids = append(oids, d.OIDs()...)
+	}
+	oids = append(oids, mib.InputOids()...)
+	sort.Strings(oids)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "name=%s\n", mib.Name)
+	fmt.Fprintf(h, "roots=%s\n", strings.Join(mib.Roots(), ","))
+	fmt.Fprintf(h, "oids=%s\n", strings.Join(oids, ","))
+	fmt.Fprintf(h, "strategy=%s\n", mib.DiscoveryStrategy)
+	fmt.Fprintf(h, "columns=%s\n", strings.Join(mib.Columns, ","))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ResolvedDevices gets the full set of devices for the MIB. For MIBs which extend
+// other MIBs, this is the device set computed at registration time. Otherwise, it
+// is the MIB's Devices.
+func (mib *MIB) ResolvedDevices() []*SnmpDevice {
+	if res := mib.resolved(); res != nil {
+		return res.devices
+	}
+	return mib.Devices
+}
+
+// Roots gets the subtree root OIDs which need to be walked in order to discover
+// the MIB's devices on an agent.
+//
+// These are the RootOid and RootOids for the MIB, along with the roots of any
+// MIBs it extends. If none are defined, they are derived from the MIB's devices,
+// using the parent OID of each device OID (see SnmpDevice.OIDs) and computed device
+// input, or the prefixes of each device whose OID is a pattern (see
+// SnmpDevice.Prefixes). The derived roots within the same subtree (see
+// subtreeDepth) are merged into their longest common prefix, so that e.g. the
+// scalars of a group are discovered with a single walk. Roots which fall within
+// another root are omitted, since walking the enclosing root already covers them.
+func (mib *MIB) Roots() []string {
+	var roots []string
+	if mib.RootOid != "" {
+		roots = append(roots, mib.RootOid)
+	}
+	roots = append(roots, mib.RootOids...)
+	if res := mib.resolved(); res != nil {
+		roots = append(roots, res.inheritedRoots...)
+	}
+
+	if len(roots) == 0 {
+		for _, d := range mib.ResolvedDevices() {
+			if d.IsPattern() {
+				roots = append(roots, d.Prefixes()...)
+				continue
+			}
+			for _, oid := range d.OIDs() {
+				oid = core.NormalizeOid(oid)
+				if idx := strings.LastIndex(oid, "."); idx > 0 {
+					roots = append(roots, oid[:idx])
+				}
+			}
+		}
+		for _, oid := range mib.InputOids() {
+			if idx := strings.LastIndex(oid, "."); idx > 0 {
+				roots = append(roots, oid[:idx])
+			}
+		}
+		roots = mergeRoots(roots)
+	}
+	return collapseRoots(roots)
+}
+
+// subtreeDepth is the number of arcs in the OID of the subtree a device-derived
+// root belongs to, e.g. a MIB-2 group (1.3.6.1.2.1.X) or an enterprise
+// (1.3.6.1.4.1.X). Roots are only merged within a subtree, so that a MIB whose
+// devices span unrelated subtrees does not walk everything in between.
+const subtreeDepth = 7
+
+// mergeRoots merges the given roots which fall within the same subtree (see
+// subtreeDepth) into their longest common prefix. The order in which the subtrees
+// first appear in the given roots is kept.
+func mergeRoots(roots []string) []string {
+	var subtrees []string
+	prefixes := map[string][]string{}
+	for _, r := range roots {
+		arcs := strings.Split(core.NormalizeOid(r), ".")
+		key := arcs
+		if len(key) > subtreeDepth {
+			key = key[:subtreeDepth]
+		}
+		subtree := strings.Join(key, ".")
+
+		prefix, exists := prefixes[subtree]
+		if !exists {
+			subtrees = append(subtrees, subtree)
+			prefixes[subtree] = arcs
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(arcs) && prefix[n] == arcs[n] {
+			n++
+		}
+		prefixes[subtree] = prefix[:n]
+	}
+
+	merged := make([]string, 0, len(subtrees))
+	for _, subtree := range subtrees {
+		merged = append(merged, strings.Join(prefixes[subtree], "."))
+	}
+	return merged
 }
 
-// LoadDevices loads Synse devices from the SNMP devices defined in the MIB.
+// Matches checks whether the MIB is implemented by an agent with the given
+// identity, based on the MIB's EnterpriseOids and CapabilityOids.
+func (mib *MIB) Matches(identity *core.AgentIdentity) bool {
+	if identity == nil {
+		return false
+	}
+	for _, prefix := range mib.EnterpriseOids {
+		if identity.SysObjectID != "" && core.OidHasPrefix(identity.SysObjectID, prefix) {
+			return true
+		}
+	}
+	for _, capability := range mib.CapabilityOids {
+		for _, c := range identity.Capabilities {
+			if core.NormalizeOid(capability) == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadDevices loads Synse devices from the SNMP devices defined in the MIB. A device
+// whose OID is a pattern is loaded once for each supported OID which matches it (see
+// ExpandedDevices). Computed devices are loaded if all of their inputs are supported.
 func (mib *MIB) LoadDevices(cfg *core.SnmpTargetConfiguration, supported map[string]struct{}) ([]*sdk.Device, error) {
 	if cfg == nil {
 		return nil, errors.New("cannot load devices with nil SNMP target config")
 	}
 
+	mibDevices := mib.ExpandedDevices(supported)
 	log.WithFields(log.Fields{
 		"mib":     mib.Name,
-		"devices": len(mib.Devices),
+		"devices": len(mibDevices),
 	}).Debug("[snmp] loading devices for MIB")
 
 	var devices []*sdk.Device
-	for _, d := range mib.Devices {
+	for _, d := range mibDevices {
 
 		if _, exists := supported[d.OID]; !exists {
 			log.WithFields(log.Fields{
@@ -71,6 +307,114 @@ func (mib *MIB) LoadDevices(cfg *core.SnmpTargetConfiguration, supported map[str
 		devices = append(devices, device)
 	}
 
+	computed, err := mib.loadComputed(cfg, supported)
+	if err != nil {
+		return nil, err
+	}
+	devices = append(devices, computed...)
+
 	log.WithFields(log.Fields{"devices": devices}).Debug("[snmp] loaded devices")
 	return devices, nil
 }
+
+// resolve computes the device set for a MIB which extends other MIBs, or which
+// defines overrides or removals. The lookup function is used to get the extended
+// MIBs by name; they must already be resolved. The MIB itself is not modified; the
+// resolution is returned so that it can be applied once it is validated. If the MIB
+// neither extends nor changes other MIBs, the returned resolution is nil.
+func (mib *MIB) resolve(lookup func(string) *MIB) (*resolution, error) {
+	if len(mib.Extends) == 0 && len(mib.Overrides) == 0 && len(mib.Removes) == 0 {
+		return nil, nil
+	}
+
+	// The devices are kept in the order in which their OIDs are first seen. A
+	// device which is removed and then redefined keeps its original position.
+	var order []string
+	seen := map[string]struct{}{}
+	devices := map[string]*SnmpDevice{}
+	add := func(d *SnmpDevice) {
+		if _, exists := seen[d.OID]; !exists {
+			seen[d.OID] = struct{}{}
+			order = append(order, d.OID)
+		}
+		devices[d.OID] = d
+	}
+
+	var roots []string
+	var parents []*MIB
+	for _, name := range mib.Extends {
+		parent := lookup(name)
+		if parent == nil {
+			return nil, fmt.Errorf("MIB %s extends unknown MIB %s", mib.Name, name)
+		}
+		for _, d := range parent.ResolvedDevices() {
+			add(d)
+		}
+		roots = append(roots, parent.Roots()...)
+		parents = append(parents, parent)
+	}
+
+	for _, oid := range mib.Removes {
+		if _, exists := devices[oid]; !exists {
+			return nil, fmt.Errorf("MIB %s removes unknown device %s", mib.Name, oid)
+		}
+		delete(devices, oid)
+	}
+
+	for _, d := range mib.Devices {
+		add(d)
+	}
+
+	for _, o := range mib.Overrides {
+		d, exists := devices[o.OID]
+		if !exists {
+			return nil, fmt.Errorf("MIB %s overrides unknown device %s", mib.Name, o.OID)
+		}
+		devices[o.OID] = d.override(o)
+	}
+
+	resolved := make([]*SnmpDevice, 0, len(devices))
+	for _, oid := range order {
+		if d, exists := devices[oid]; exists {
+			resolved = append(resolved, d)
+		}
+	}
+
+	return &resolution{
+		devices:        resolved,
+		computed:       mib.resolveComputed(parents),
+		inheritedRoots: roots,
+	}, nil
+}
+
+// collapseRoots removes duplicate root OIDs, as well as any root OID which falls
+// within the subtree of another root OID. The order of the given roots is kept.
+func collapseRoots(roots []string) []string {
+	// Sort a copy of the roots so that enclosing roots come before the roots
+	// they enclose.
+	sorted := make([]string, len(roots))
+	for i, r := range roots {
+		sorted[i] = core.NormalizeOid(r)
+	}
+	sort.Strings(sorted)
+
+	keep := map[string]struct{}{}
+	var last string
+	for _, r := range sorted {
+		if last != "" && core.OidHasPrefix(r, last) {
+			continue
+		}
+		keep[r] = struct{}{}
+		last = r
+	}
+
+	var collapsed []string
+	for _, r := range roots {
+		r = core.NormalizeOid(r)
+		if _, ok := keep[r]; ok {
+			collapsed = append(collapsed, r)
+			delete(keep, r)
+		}
+	}
+	return collapsed
+}
@@ -190,3 +190,184 @@ func TestMIB_LoadDevices_deviceNotSupported(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, devices)
 }
+
+func TestMIB_Matches(t *testing.T) {
+	mib := MIB{
+		Name:           "test-mib",
+		EnterpriseOids: []string{"1.3.6.1.4.1.534"},
+		CapabilityOids: []string{"1.3.6.1.2.1.33"},
+	}
+
+	tests := []struct {
+		name     string
+		identity *core.AgentIdentity
+		expected bool
+	}{
+		{
+			name:     "nil identity",
+			identity: nil,
+			expected: false,
+		},
+		{
+			name:     "sysObjectID within enterprise",
+			identity: &core.AgentIdentity{SysObjectID: "1.3.6.1.4.1.534.1"},
+			expected: true,
+		},
+		{
+			name:     "sysObjectID outside enterprise",
+			identity: &core.AgentIdentity{SysObjectID: "1.3.6.1.4.1.5345.1"},
+			expected: false,
+		},
+		{
+			name: "capability advertised",
+			identity: &core.AgentIdentity{
+				SysObjectID:  "1.3.6.1.4.1.9999",
+				Capabilities: []string{"1.3.6.1.6.3.1", "1.3.6.1.2.1.33"},
+			},
+			expected: true,
+		},
+		{
+			name: "capability not advertised",
+			identity: &core.AgentIdentity{
+				SysObjectID:  "1.3.6.1.4.1.9999",
+				Capabilities: []string{"1.3.6.1.6.3.1"},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, mib.Matches(test.identity))
+		})
+	}
+}
+
+func TestMIB_String_MultipleRoots(t *testing.T) {
+	mib := MIB{
+		Name:     "name",
+		RootOid:  "1.3.6.1.4.1.534",
+		RootOids: []string{"1.3.6.1.2.1.33"},
+	}
+	assert.Equal(t, "[MIB name (1.3.6.1.4.1.534, 1.3.6.1.2.1.33)]", mib.String())
+}
+
+func TestMIB_Roots(t *testing.T) {
+	tests := []struct {
+		name     string
+		mib      MIB
+		expected []string
+	}{
+		{
+			name:     "single root",
+			mib:      MIB{RootOid: "1.3.6.1.2.1.33"},
+			expected: []string{"1.3.6.1.2.1.33"},
+		},
+		{
+			name: "multiple roots",
+			mib: MIB{
+				RootOid:  "1.3.6.1.4.1.534",
+				RootOids: []string{"1.3.6.1.2.1.33"},
+			},
+			expected: []string{"1.3.6.1.4.1.534", "1.3.6.1.2.1.33"},
+		},
+		{
+			name: "nested and duplicate roots",
+			mib: MIB{
+				RootOid:  "1.3.6.1.2.1.33",
+				RootOids: []string{"1.3.6.1.2.1.33.1.2", ".1.3.6.1.2.1.33", "1.3.6.1.2.1.330"},
+			},
+			expected: []string{"1.3.6.1.2.1.33", "1.3.6.1.2.1.330"},
+		},
+		{
+			name: "derived from devices",
+			mib: MIB{
+				Devices: []*SnmpDevice{
+					{OID: "1.3.6.1.4.1.534.1.2.1.0"},
+					{OID: "1.3.6.1.4.1.534.1.2.2.0"},
+					{OID: "1.3.6.1.2.1.33.1.2.3.0"},
+				},
+			},
+			expected: []string{"1.3.6.1.4.1.534.1.2", "1.3.6.1.2.1.33.1.2.3"},
+		},
+		{
+			name: "derived from scalars in one group",
+			mib: MIB{
+				Devices: []*SnmpDevice{
+					{OID: "1.3.6.1.4.1.534.1.2.1.0"},
+					{OID: "1.3.6.1.4.1.534.1.2.2.0"},
+					{OID: "1.3.6.1.4.1.534.1.2.3.0"},
+					{OID: "1.3.6.1.4.1.534.1.2.4.0"},
+				},
+			},
+			expected: []string{"1.3.6.1.4.1.534.1.2"},
+		},
+		{
+			name: "derived from table columns",
+			mib: MIB{
+				Devices: []*SnmpDevice{
+					{OID: "1.3.6.1.2.1.33.1.4.4.1.2.1"},
+					{OID: "1.3.6.1.2.1.33.1.4.4.1.3.1"},
+					{OID: "1.3.6.1.2.1.33.1.4.4.1.2.2"},
+				},
+			},
+			expected: []string{"1.3.6.1.2.1.33.1.4.4.1"},
+		},
+		{
+			name: "derived from device readings",
+			mib: MIB{
+				Devices: []*SnmpDevice{
+					{OID: "1.3.6.1.2.1.33.1.2.1.0", Readings: []*core.ReadingOid{
+						{OID: "1.3.6.1.2.1.33.1.3.1.0"},
+					}},
+				},
+			},
+			expected: []string{"1.3.6.1.2.1.33.1"},
+		},
+		{
+			name: "derived from device patterns",
+			mib: MIB{
+				Devices: []*SnmpDevice{
+					{OID: "1.3.6.1.4.1.534.6.6.7.6.6.1.2.*.*"},
+					{OID: "1.3.6.1.2.1.33.1.2.**"},
+				},
+			},
+			expected: []string{"1.3.6.1.4.1.534.6.6.7.6.6.1.2", "1.3.6.1.2.1.33.1.2"},
+		},
+		{
+			name:     "no roots or devices",
+			mib:      MIB{},
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.mib.Roots())
+		})
+	}
+}
+
+func TestMIB_Hash(t *testing.T) {
+	mib := &MIB{
+		Name:    "test-mib",
+		RootOid: "1.2.3",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.4", Info: "device 1"},
+			{OID: "1.2.3.5", Info: "device 2"},
+		},
+	}
+	hash := mib.Hash()
+	assert.Len(t, hash, 64)
+
+	// Changes which do not affect discovery do not change the hash.
+	mib.Devices[0].Info = "renamed device"
+	assert.Equal(t, hash, mib.Hash())
+
+	mib.Devices = append(mib.Devices, &SnmpDevice{OID: "1.2.3.6"})
+	assert.NotEqual(t, hash, mib.Hash())
+	hash = mib.Hash()
+
+	mib.Computed = append(mib.Computed, &ComputedDevice{Key: "total", Inputs: []string{"1.2.3.7"}})
+	assert.NotEqual(t, hash, mib.Hash())
+}
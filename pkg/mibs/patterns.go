@@ -0,0 +1,187 @@
+package mibs
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// Components of SnmpDevice OID patterns.
+const (
+	// WildcardComponent matches any single component of an OID.
+	WildcardComponent = "*"
+
+	// SubtreeComponent matches one or more trailing components of an OID. It
+	// may only be the last component of a pattern.
+	SubtreeComponent = "**"
+)
+
+// oidPatternPattern matches a well-formed OID pattern: a numeric OID, any of whose
+// components (other than the first) may be a wildcard, optionally ending with a
+// subtree component.
+var oidPatternPattern = regexp.MustCompile(`^[0-9]+(\.([0-9]+|\*))*(\.\*\*)?$`)
+
+// IsPattern checks whether the device's OID is a pattern which matches multiple
+// OIDs, rather than a single OID.
+func (device *SnmpDevice) IsPattern() bool {
+	return strings.Contains(device.OID, WildcardComponent)
+}
+
+// Prefix gets the part of the device's OID before its first wildcard or subtree
+// component. For devices whose OID is not a pattern, this is the OID itself.
+func (device *SnmpDevice) Prefix() string {
+	return oidPrefix(device.OID)
+}
+
+// Prefixes gets the prefix (see Prefix) of each of the OIDs the device reports
+// readings for (see OIDs).
+func (device *SnmpDevice) Prefixes() []string {
+	var prefixes []string
+	for _, oid := range device.OIDs() {
+		prefixes = append(prefixes, oidPrefix(oid))
+	}
+	return prefixes
+}
+
+// oidPrefix gets the part of an OID pattern before its first wildcard or subtree
+// component.
+func oidPrefix(oid string) string {
+	var prefix []string
+	for _, component := range strings.Split(oid, ".") {
+		if component == WildcardComponent || component == SubtreeComponent {
+			break
+		}
+		prefix = append(prefix, component)
+	}
+	return strings.Join(prefix, ".")
+}
+
+// Match checks whether the given OID matches the device's OID pattern. If it does,
+// the components of the OID matched by each wildcard are returned in order. The
+// trailing components matched by a subtree component are returned as one value.
+func (device *SnmpDevice) Match(oid string) ([]string, bool) {
+	pattern := strings.Split(device.OID, ".")
+	components := strings.Split(core.NormalizeOid(oid), ".")
+
+	matched := []string{}
+	for i, p := range pattern {
+		if p == SubtreeComponent {
+			if i >= len(components) {
+				return nil, false
+			}
+			return append(matched, strings.Join(components[i:], ".")), true
+		}
+		if i >= len(components) {
+			return nil, false
+		}
+		if p == WildcardComponent {
+			matched = append(matched, components[i])
+		} else if p != components[i] {
+			return nil, false
+		}
+	}
+	if len(components) != len(pattern) {
+		return nil, false
+	}
+	return matched, true
+}
+
+// expand creates a copy of the device for an OID which matches the device's OID
+// pattern. The matched components are added to the device Data and Context as
+// "index" (all matched components, joined with dots), and replace the "{index}"
+// and "{1}", "{2}", ... placeholders in the device Info and Alias, as well as the
+// wildcard components of the OIDs of the device's additional Readings.
+func (device *SnmpDevice) expand(oid string, matched []string) *SnmpDevice {
+	d := *device
+	d.OID = oid
+
+	index := strings.Join(matched, ".")
+	replacements := []string{"{index}", index}
+	for i, m := range matched {
+		replacements = append(replacements, "{"+strconv.Itoa(i+1)+"}", m)
+	}
+	replacer := strings.NewReplacer(replacements...)
+	d.Info = replacer.Replace(device.Info)
+	d.Alias = replacer.Replace(device.Alias)
+
+	d.Data = map[string]interface{}{}
+	for k, v := range device.Data {
+		d.Data[k] = v
+	}
+	d.Data["index"] = index
+
+	d.Context = map[string]string{}
+	for k, v := range device.Context {
+		d.Context[k] = v
+	}
+	d.Context["index"] = index
+
+	if device.Readings != nil {
+		d.Readings = make([]*core.ReadingOid, len(device.Readings))
+		for i, r := range device.Readings {
+			reading := *r
+			reading.OID = substitute(r.OID, matched)
+			d.Readings[i] = &reading
+		}
+	}
+	return &d
+}
+
+// substitute replaces the wildcard and subtree components of an OID pattern with
+// the given matched components, in order.
+func substitute(pattern string, matched []string) string {
+	components := strings.Split(core.NormalizeOid(pattern), ".")
+	i := 0
+	for j, c := range components {
+		if (c == WildcardComponent || c == SubtreeComponent) && i < len(matched) {
+			components[j] = matched[i]
+			i++
+		}
+	}
+	return strings.Join(components, ".")
+}
+
+// wildcards gets the wildcard and subtree components of an OID pattern, in order.
+func wildcards(pattern string) []string {
+	var components []string
+	for _, c := range strings.Split(pattern, ".") {
+		if c == WildcardComponent || c == SubtreeComponent {
+			components = append(components, c)
+		}
+	}
+	return components
+}
+
+// ExpandedDevices gets the devices of the MIB (see ResolvedDevices), with each
+// device whose OID is a pattern replaced by one device for each of the given OIDs
+// which match the pattern, in OID order. Devices whose OID is not a pattern are
+// included as-is.
+func (mib *MIB) ExpandedDevices(oids map[string]struct{}) []*SnmpDevice {
+	var sorted []string
+	var devices []*SnmpDevice
+	for _, d := range mib.ResolvedDevices() {
+		if !d.IsPattern() {
+			devices = append(devices, d)
+			continue
+		}
+
+		if sorted == nil {
+			sorted = make([]string, 0, len(oids))
+			for oid := range oids {
+				sorted = append(sorted, oid)
+			}
+			sort.Slice(sorted, func(i, j int) bool {
+				return core.CompareOids(sorted[i], sorted[j]) < 0
+			})
+		}
+		for _, oid := range sorted {
+			if matched, ok := d.Match(oid); ok {
+				devices = append(devices, d.expand(oid, matched))
+			}
+		}
+	}
+	return devices
+}
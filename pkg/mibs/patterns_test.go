@@ -0,0 +1,158 @@
+package mibs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+func TestSnmpDevice_IsPattern(t *testing.T) {
+	assert.False(t, (&SnmpDevice{OID: "1.2.3.4"}).IsPattern())
+	assert.True(t, (&SnmpDevice{OID: "1.2.3.*"}).IsPattern())
+	assert.True(t, (&SnmpDevice{OID: "1.2.3.**"}).IsPattern())
+}
+
+func TestSnmpDevice_Prefix(t *testing.T) {
+	assert.Equal(t, "1.2.3.4", (&SnmpDevice{OID: "1.2.3.4"}).Prefix())
+	assert.Equal(t, "1.2.3", (&SnmpDevice{OID: "1.2.3.*.5"}).Prefix())
+	assert.Equal(t, "1.2.3", (&SnmpDevice{OID: "1.2.3.**"}).Prefix())
+}
+
+func TestSnmpDevice_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		oid      string
+		matched  []string
+		expected bool
+	}{
+		{"exact", "1.2.3.4", "1.2.3.4", []string{}, true},
+		{"exact mismatch", "1.2.3.4", "1.2.3.5", nil, false},
+		{"wildcard", "1.2.3.*", "1.2.3.7", []string{"7"}, true},
+		{"wildcard leading dot", "1.2.3.*", ".1.2.3.7", []string{"7"}, true},
+		{"multiple wildcards", "1.2.*.4.*", "1.2.3.4.5", []string{"3", "5"}, true},
+		{"wildcard too short", "1.2.3.*", "1.2.3", nil, false},
+		{"wildcard too long", "1.2.3.*", "1.2.3.7.1", nil, false},
+		{"wildcard mismatch", "1.2.*.4", "1.2.3.5", nil, false},
+		{"subtree", "1.2.3.**", "1.2.3.7.1", []string{"7.1"}, true},
+		{"subtree single", "1.2.3.**", "1.2.3.7", []string{"7"}, true},
+		{"subtree root", "1.2.3.**", "1.2.3", nil, false},
+		{"wildcard and subtree", "1.2.*.4.**", "1.2.3.4.5.6", []string{"3", "5.6"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matched, ok := (&SnmpDevice{OID: test.pattern}).Match(test.oid)
+			assert.Equal(t, test.expected, ok)
+			assert.Equal(t, test.matched, matched)
+		})
+	}
+}
+
+func TestMIB_ExpandedDevices(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.4", Info: "device"},
+			{
+				OID:     "1.2.5.*.*",
+				Info:    "outlet {2} (bank {1})",
+				Alias:   "outlet-{index}",
+				Data:    map[string]interface{}{"foo": "bar"},
+				Context: map[string]string{"foo": "bar"},
+			},
+		},
+	}
+
+	devices := mib.ExpandedDevices(map[string]struct{}{
+		"1.2.3.4":    {},
+		"1.2.5.1.10": {},
+		"1.2.5.1.2":  {},
+		"1.2.6.1.1":  {},
+	})
+	assert.Len(t, devices, 3)
+
+	assert.Equal(t, "1.2.3.4", devices[0].OID)
+	assert.Equal(t, "device", devices[0].Info)
+
+	assert.Equal(t, "1.2.5.1.2", devices[1].OID)
+	assert.Equal(t, "outlet 2 (bank 1)", devices[1].Info)
+	assert.Equal(t, "outlet-1.2", devices[1].Alias)
+	assert.Equal(t, map[string]interface{}{"foo": "bar", "index": "1.2"}, devices[1].Data)
+	assert.Equal(t, map[string]string{"foo": "bar", "index": "1.2"}, devices[1].Context)
+
+	assert.Equal(t, "1.2.5.1.10", devices[2].OID)
+	assert.Equal(t, "outlet 10 (bank 1)", devices[2].Info)
+
+	// The pattern device itself is not modified.
+	assert.Equal(t, "1.2.5.*.*", mib.Devices[1].OID)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, mib.Devices[1].Data)
+}
+
+func TestMIB_LoadDevices_Pattern(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.*", Info: "sensor {index}", Type: "temperature", Handler: "read-only", Output: "temperature"},
+		},
+	}
+
+	cfg := &core.SnmpTargetConfiguration{
+		MIB:     "test-mib",
+		Version: "v2",
+		Agent:   "localhost",
+	}
+	devices, err := mib.LoadDevices(cfg, map[string]struct{}{
+		"1.2.3.1": {},
+		"1.2.3.2": {},
+		"1.2.4.1": {},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	assert.Equal(t, "sensor 1", devices[0].Info)
+	assert.Equal(t, "1.2.3.1", devices[0].Data["oid"])
+	assert.Equal(t, "1", devices[0].Data["index"])
+	assert.Equal(t, map[string]string{"oid": "1.2.3.1", "index": "1"}, devices[0].Context)
+
+	assert.Equal(t, "sensor 2", devices[1].Info)
+	assert.Equal(t, "1.2.3.2", devices[1].Data["oid"])
+}
+
+func TestSnmpDevice_Prefixes(t *testing.T) {
+	d := &SnmpDevice{
+		OID: "1.2.3.*",
+		Readings: []*core.ReadingOid{
+			{OID: "1.2.4.*", Output: "electric-current"},
+		},
+	}
+	assert.Equal(t, []string{"1.2.3", "1.2.4"}, d.Prefixes())
+}
+
+func TestMIB_ExpandedDevices_Readings(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{
+				OID:  "1.2.3.*.**",
+				Info: "outlet {index}",
+				Readings: []*core.ReadingOid{
+					{OID: "1.2.4.*.**", Output: "electric-current", Context: map[string]string{"foo": "bar"}},
+				},
+			},
+		},
+	}
+
+	devices := mib.ExpandedDevices(map[string]struct{}{
+		"1.2.3.1.2.3": {},
+	})
+	assert.Len(t, devices, 1)
+	assert.Equal(t, "1.2.3.1.2.3", devices[0].OID)
+	assert.Equal(t, []*core.ReadingOid{
+		{OID: "1.2.4.1.2.3", Output: "electric-current", Context: map[string]string{"foo": "bar"}},
+	}, devices[0].Readings)
+
+	// The pattern device itself is not modified.
+	assert.Equal(t, "1.2.4.*.**", mib.Devices[0].Readings[0].OID)
+}
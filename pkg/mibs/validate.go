@@ -0,0 +1,149 @@
+package mibs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/soniah/gosnmp"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/handlers"
+)
+
+// oidPattern matches a well-formed numeric OID without a leading dot.
+var oidPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// ValidationError is the error returned when a MIB definition is invalid. It
+// holds all of the problems found with the MIB's devices.
+type ValidationError struct {
+	MIB      string
+	Problems []string
+}
+
+// Error returns the error string, listing all problems found.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid MIB %s: %s", e.MIB, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks each device of the MIB, returning a ValidationError which lists
+// every problem found. If the MIB is valid, nil is returned.
+//
+// A device is valid if it has a well-formed OID (or OID pattern, see
+// SnmpDevice.Match) which is unique within the MIB, a Type, a Handler which is
+// known to the SNMP plugin base (see handlers.Register), an Output which is
+// registered with the SDK, and, if set, a known SMI type and a condition on a
+// well-formed OID with at least one value. Since handlers and outputs are checked
+// against those currently registered, any custom handlers or outputs should be
+// registered before the MIB is validated. A device's additional readings must have
+// well-formed OIDs and registered outputs (see validateReadings).
+//
+// A computed device is valid if it has a well-formed key which is unique within the
+// MIB, a Type, a registered Output, between one and gosnmp.MaxOids well-formed input
+// OIDs, and either a valid expression or a function.
+//
+// The MIB's discovery strategy must be supported and its table columns must be
+// well-formed OIDs.
+func (mib *MIB) Validate() error {
+	var problems []string
+	seen := map[string]struct{}{}
+
+	for _, d := range mib.ResolvedDevices() {
+		if d == nil {
+			problems = append(problems, "nil device")
+			continue
+		}
+
+		if d.IsPattern() {
+			if !oidPatternPattern.MatchString(d.OID) {
+				problems = append(problems, fmt.Sprintf("device %q: malformed OID pattern", d.OID))
+			}
+		} else if !oidPattern.MatchString(d.OID) {
+			problems = append(problems, fmt.Sprintf("device %q: malformed OID", d.OID))
+		}
+		if _, exists := seen[d.OID]; exists {
+			problems = append(problems, fmt.Sprintf("device %s: duplicate OID", d.OID))
+		}
+		seen[d.OID] = struct{}{}
+
+		if d.Type == "" {
+			problems = append(problems, fmt.Sprintf("device %s: no type specified", d.OID))
+		}
+		if handlers.Get(d.Handler) == nil {
+			problems = append(problems, fmt.Sprintf("device %s: unknown handler %q", d.OID, d.Handler))
+		}
+		if output.Get(d.Output) == nil {
+			problems = append(problems, fmt.Sprintf("device %s: output %q not registered", d.OID, d.Output))
+		}
+		if d.SMIType != "" && !core.IsSMIType(d.SMIType) {
+			problems = append(problems, fmt.Sprintf("device %s: unknown SMI type %q", d.OID, d.SMIType))
+		}
+		if d.Condition != nil {
+			if !oidPattern.MatchString(d.Condition.OID) {
+				problems = append(problems, fmt.Sprintf("device %s: malformed condition OID %q", d.OID, d.Condition.OID))
+			}
+			if len(d.Condition.Values) == 0 {
+				problems = append(problems, fmt.Sprintf("device %s: condition has no values", d.OID))
+			}
+		}
+		problems = append(problems, validateReadings(d)...)
+	}
+
+	problems = append(problems, mib.validateComputed()...)
+
+	switch mib.DiscoveryStrategy {
+	case "", core.DiscoveryWalk, core.DiscoveryProbe:
+	default:
+		problems = append(problems, fmt.Sprintf("unsupported discovery strategy %q", mib.DiscoveryStrategy))
+	}
+	for _, column := range mib.Columns {
+		if !oidPattern.MatchString(column) {
+			problems = append(problems, fmt.Sprintf("column %q: malformed OID", column))
+		}
+	}
+
+	if len(problems) != 0 {
+		return &ValidationError{
+			MIB:      mib.Name,
+			Problems: problems,
+		}
+	}
+	return nil
+}
+
+// validateReadings checks the additional Readings of a device, returning the problems
+// found. Reading OIDs must be well-formed and distinct from the device's other OIDs,
+// and all of the device's OIDs must fit in a single GET request (gosnmp.MaxOids).
+// For devices whose OID is a pattern, reading OIDs must be patterns with the same
+// wildcard components as the device's OID.
+func validateReadings(d *SnmpDevice) []string {
+	var problems []string
+	if len(d.Readings)+1 > gosnmp.MaxOids {
+		problems = append(problems, fmt.Sprintf("device %s: more than %d reading OIDs", d.OID, gosnmp.MaxOids))
+	}
+
+	seen := map[string]struct{}{d.OID: {}}
+	for _, r := range d.Readings {
+		if r == nil {
+			problems = append(problems, fmt.Sprintf("device %s: nil reading", d.OID))
+			continue
+		}
+
+		if d.IsPattern() {
+			if !oidPatternPattern.MatchString(r.OID) || strings.Join(wildcards(r.OID), ".") != strings.Join(wildcards(d.OID), ".") {
+				problems = append(problems, fmt.Sprintf("device %s: reading OID %q does not match device OID pattern", d.OID, r.OID))
+			}
+		} else if !oidPattern.MatchString(r.OID) {
+			problems = append(problems, fmt.Sprintf("device %s: malformed reading OID %q", d.OID, r.OID))
+		}
+		if _, exists := seen[r.OID]; exists {
+			problems = append(problems, fmt.Sprintf("device %s: duplicate reading OID %s", d.OID, r.OID))
+		}
+		seen[r.OID] = struct{}{}
+
+		if output.Get(r.Output) == nil {
+			problems = append(problems, fmt.Sprintf("device %s: reading output %q not registered", d.OID, r.Output))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,236 @@
+package mibs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+func TestMIB_Validate(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{
+				OID:     "1.2.3.4",
+				Info:    "test device 1",
+				Type:    "temperature",
+				Handler: "read-only",
+				Output:  "temperature",
+			},
+			{
+				OID:     "1.2.3.5",
+				Info:    "test device 2",
+				Type:    "state",
+				Handler: "read-write",
+				Output:  "state",
+			},
+		},
+	}
+
+	assert.NoError(t, mib.Validate())
+}
+
+func TestMIB_Validate_NoDevices(t *testing.T) {
+	mib := MIB{Name: "test-mib"}
+	assert.NoError(t, mib.Validate())
+}
+
+func TestMIB_Validate_Errors(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{
+				OID:     ".1.2.3.4",
+				Type:    "temperature",
+				Handler: "read-only",
+				Output:  "temperature",
+			},
+			{
+				OID:     "1.2.3.5",
+				Type:    "temperature",
+				Handler: "read-only",
+				Output:  "temperature",
+			},
+			{
+				OID:     "1.2.3.5",
+				Handler: "unknown-handler",
+				Output:  "unknown-output",
+			},
+			nil,
+		},
+	}
+
+	err := mib.Validate()
+	assert.Error(t, err)
+
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, "test-mib", verr.MIB)
+	assert.Equal(t, []string{
+		`device ".1.2.3.4": malformed OID`,
+		"device 1.2.3.5: duplicate OID",
+		"device 1.2.3.5: no type specified",
+		`device 1.2.3.5: unknown handler "unknown-handler"`,
+		`device 1.2.3.5: output "unknown-output" not registered`,
+		"nil device",
+	}, verr.Problems)
+}
+
+func TestMIB_Validate_Discovery(t *testing.T) {
+	mib := MIB{
+		Name:              "test-mib",
+		DiscoveryStrategy: "scan",
+		Columns:           []string{"1.2.3.4", "1.2.x"},
+	}
+
+	err := mib.Validate()
+	assert.Error(t, err)
+
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`unsupported discovery strategy "scan"`,
+		`column "1.2.x": malformed OID`,
+	}, verr.Problems)
+}
+
+func TestMIB_Validate_SMIType(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.4", Type: "temperature", Handler: "read-only", Output: "temperature", SMIType: core.SMIGauge32},
+			{OID: "1.2.3.5", Type: "temperature", Handler: "read-only", Output: "temperature", SMIType: "Float"},
+		},
+	}
+
+	err := mib.Validate()
+	assert.Error(t, err)
+
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`device 1.2.3.5: unknown SMI type "Float"`,
+	}, verr.Problems)
+}
+
+func TestMIB_Validate_Condition(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.4", Type: "temperature", Handler: "read-only", Output: "temperature", Condition: &Condition{OID: "1.2.3.1", Values: []interface{}{1}}},
+			{OID: "1.2.3.5", Type: "temperature", Handler: "read-only", Output: "temperature", Condition: &Condition{OID: "1.2.x"}},
+		},
+	}
+
+	err := mib.Validate()
+	assert.Error(t, err)
+
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`device 1.2.3.5: malformed condition OID "1.2.x"`,
+		"device 1.2.3.5: condition has no values",
+	}, verr.Problems)
+}
+
+func TestMIB_Validate_Pattern(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.*", Type: "temperature", Handler: "read-only", Output: "temperature"},
+			{OID: "1.2.*.4.**", Type: "temperature", Handler: "read-only", Output: "temperature"},
+			{OID: "1.2.3.**.5", Type: "temperature", Handler: "read-only", Output: "temperature"},
+			{OID: "*.2.3", Type: "temperature", Handler: "read-only", Output: "temperature"},
+			{OID: "1.2.3.4*", Type: "temperature", Handler: "read-only", Output: "temperature"},
+		},
+	}
+
+	err := mib.Validate()
+	assert.Error(t, err)
+
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`device "1.2.3.**.5": malformed OID pattern`,
+		`device "*.2.3": malformed OID pattern`,
+		`device "1.2.3.4*": malformed OID pattern`,
+	}, verr.Problems)
+}
+
+func TestMIB_Validate_Computed(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Computed: []*ComputedDevice{
+			{Key: "power", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1", "1.2.3.2"}, Expression: "$1 * $2"},
+			{Key: "charge", Type: "battery", Output: "percentage", Inputs: []string{"1.2.3.4"}, Func: func(values []interface{}) (interface{}, error) {
+				return values[0], nil
+			}},
+			{Key: "1.2.3", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1"}, Expression: "$1"},
+			{Key: "power", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1"}, Expression: "$1"},
+			{Key: "no-type", Output: "unknown", Inputs: []string{"1.2.3.1"}, Expression: "$1"},
+			{Key: "no-inputs", Type: "power", Output: "watt", Expression: "1"},
+			{Key: "bad-input", Type: "power", Output: "watt", Inputs: []string{"1.2.*"}, Expression: "$1"},
+			{Key: "no-compute", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1"}},
+			{Key: "both", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1"}, Expression: "$1", Func: func(values []interface{}) (interface{}, error) {
+				return values[0], nil
+			}},
+			{Key: "bad-expression", Type: "power", Output: "watt", Inputs: []string{"1.2.3.1"}, Expression: "$1 * $2"},
+			nil,
+		},
+	}
+
+	err := mib.Validate()
+	assert.Error(t, err)
+
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`computed device "1.2.3": malformed key`,
+		`computed device power: duplicate key`,
+		`computed device no-type: no type specified`,
+		`computed device no-type: output "unknown" not registered`,
+		`computed device no-inputs: no inputs specified`,
+		`computed device bad-input: malformed input OID "1.2.*"`,
+		`computed device no-compute: no expression or function specified`,
+		`computed device both: both expression and function specified`,
+		`computed device bad-expression: invalid expression "$1 * $2" at position 5: unknown input (have 1 inputs)`,
+		`nil computed device`,
+	}, verr.Problems)
+}
+
+func TestMIB_Validate_Readings(t *testing.T) {
+	mib := MIB{
+		Name: "test-mib",
+		Devices: []*SnmpDevice{
+			{OID: "1.2.3.4", Type: "power", Handler: "read-only", Output: "status", Readings: []*core.ReadingOid{
+				{OID: "1.2.3.5", Output: "electric-current"},
+				{OID: "1.2.3.5", Output: "watt"},
+				{OID: "1.2.3.4", Output: "watt"},
+				{OID: "1.2.*", Output: "watt"},
+				{OID: "1.2.3.6", Output: "unknown"},
+				nil,
+			}},
+			{OID: "1.2.4.*", Type: "power", Handler: "read-only", Output: "status", Readings: []*core.ReadingOid{
+				{OID: "1.2.5.*", Output: "electric-current"},
+				{OID: "1.2.6.1", Output: "watt"},
+				{OID: "1.2.7.**", Output: "watt"},
+			}},
+		},
+	}
+
+	err := mib.Validate()
+	assert.Error(t, err)
+
+	verr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		"device 1.2.3.4: duplicate reading OID 1.2.3.5",
+		"device 1.2.3.4: duplicate reading OID 1.2.3.4",
+		`device 1.2.3.4: malformed reading OID "1.2.*"`,
+		`device 1.2.3.4: reading output "unknown" not registered`,
+		"device 1.2.3.4: nil reading",
+		`device 1.2.4.*: reading OID "1.2.6.1" does not match device OID pattern`,
+		`device 1.2.4.*: reading OID "1.2.7.**" does not match device OID pattern`,
+	}, verr.Problems)
+}
@@ -0,0 +1,221 @@
+package exp
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-sdk/sdk/config"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// DefaultWorkers is the default number of targets which a Registrar discovers
+// concurrently.
+const DefaultWorkers = 10
+
+// RegistrarOption is an option for configuring a Registrar.
+type RegistrarOption func(*Registrar)
+
+// WithWorkers is a RegistrarOption which sets the maximum number of targets which
+// are discovered concurrently. Values less than one are treated as one.
+func WithWorkers(workers int) RegistrarOption {
+	return func(r *Registrar) {
+		if workers < 1 {
+			workers = 1
+		}
+		r.workers = workers
+	}
+}
+
+// TargetError is the error for a single target which failed registration when
+// registering multiple targets.
+type TargetError struct {
+	Agent string
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *TargetError) Error() string {
+	return fmt.Sprintf("agent %s: %v", e.Agent, e.Err)
+}
+
+// Unwrap returns the underlying registration error.
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// DiscoverySummary summarizes the registration of multiple targets.
+type DiscoverySummary struct {
+	// Targets is the number of targets registration was attempted for.
+	Targets int
+
+	// Registered is the number of targets whose devices were discovered.
+	Registered int
+
+	// Degraded is the number of registered targets whose agent does not
+	// support all of the required devices of its MIBs.
+	Degraded int
+
+	// Pending is the number of deferred targets whose discovery is being
+	// retried in the background.
+	Pending int
+
+	// Failed is the number of targets which failed registration.
+	Failed int
+
+	// Devices is the total number of devices registered across all targets.
+	Devices int
+
+	// Duration is the time taken to register all of the targets.
+	Duration time.Duration
+
+	// Errors holds the error for each target which failed registration.
+	Errors []*TargetError
+}
+
+// queuedTarget is a target configuration queued for concurrent registration,
+// along with the result of its registration.
+type queuedTarget struct {
+	data    map[string]interface{}
+	devices []*sdk.Device
+}
+
+// Prepare queues a target configuration so that it is registered concurrently with
+// all other queued targets on the first call to Register. It has the signature of an
+// SDK dynamic device config registrar, which the SDK calls for every target before
+// it calls the dynamic device registrar for any of them. Prepare does not generate
+// any device configs itself.
+func (r *Registrar) Prepare(data map[string]interface{}) ([]*config.DeviceProto, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.queue = append(r.queue, &queuedTarget{data: data})
+	return nil, nil
+}
+
+// RegisterAll builds the Synse devices for multiple SNMP targets, discovering up to
+// the registrar's worker limit of targets concurrently. A target which fails
+// registration does not affect the others; its error is included in the returned
+// summary, which is also logged.
+func (r *Registrar) RegisterAll(configs []map[string]interface{}) ([]*sdk.Device, *DiscoverySummary) {
+	queue := make([]*queuedTarget, len(configs))
+	for i, data := range configs {
+		queue[i] = &queuedTarget{data: data}
+	}
+
+	summary := r.registerBatch(queue)
+
+	var devices []*sdk.Device
+	for _, q := range queue {
+		devices = append(devices, q.devices...)
+	}
+	return devices, summary
+}
+
+// Summary gets the summary of the registration of the targets queued with Prepare.
+// It is nil until the queued targets have been registered.
+func (r *Registrar) Summary() *DiscoverySummary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.summary
+}
+
+// queued gets the queued target for the given target configuration, or nil if the
+// configuration was not queued with Prepare.
+//
+// The SDK passes the same configuration map to both Prepare and Register, so the
+// queued configuration is matched by map identity rather than by its contents,
+// which may be the same for multiple targets.
+func (r *Registrar) queued(data map[string]interface{}) *queuedTarget {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ptr := reflect.ValueOf(data).Pointer()
+	for _, q := range r.queue {
+		if reflect.ValueOf(q.data).Pointer() == ptr {
+			return q
+		}
+	}
+	return nil
+}
+
+// registerQueued registers all of the targets queued with Prepare.
+func (r *Registrar) registerQueued() {
+	r.mu.RLock()
+	queue := r.queue
+	r.mu.RUnlock()
+
+	summary := r.registerBatch(queue)
+
+	r.mu.Lock()
+	r.summary = summary
+	r.mu.Unlock()
+}
+
+// registerBatch registers the given targets concurrently, bounded by the registrar's
+// worker limit, and summarizes the results.
+func (r *Registrar) registerBatch(queue []*queuedTarget) *DiscoverySummary {
+	log.WithFields(log.Fields{
+		"targets": len(queue),
+		"workers": r.workers,
+	}).Info("[snmp] discovering devices for agents")
+
+	start := time.Now()
+	targets := make([]*core.Target, len(queue))
+	errs := make([]error, len(queue))
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, r.workers)
+	for i, q := range queue {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, q *queuedTarget) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			targets[i], q.devices, errs[i] = r.register(q.data)
+		}(i, q)
+	}
+	wg.Wait()
+
+	summary := &DiscoverySummary{
+		Targets:  len(queue),
+		Duration: time.Since(start),
+	}
+	for i, q := range queue {
+		if errs[i] != nil {
+			agent := fmt.Sprint(q.data["agent"])
+			log.WithError(errs[i]).WithField("agent", agent).Error("[snmp] failed to register devices for agent")
+
+			summary.Failed++
+			summary.Errors = append(summary.Errors, &TargetError{Agent: agent, Err: errs[i]})
+			continue
+		}
+		switch targets[i].Status().State {
+		case core.TargetPending:
+			summary.Pending++
+		case core.TargetDegraded:
+			summary.Registered++
+			summary.Degraded++
+		default:
+			summary.Registered++
+		}
+		summary.Devices += len(q.devices)
+	}
+
+	log.WithFields(log.Fields{
+		"targets":    summary.Targets,
+		"registered": summary.Registered,
+		"degraded":   summary.Degraded,
+		"pending":    summary.Pending,
+		"failed":     summary.Failed,
+		"devices":    summary.Devices,
+		"duration":   summary.Duration,
+	}).Info("[snmp] finished discovering devices for agents")
+	return summary
+}
@@ -0,0 +1,127 @@
+package exp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		expected int
+	}{
+		{"default", 0, DefaultWorkers},
+		{"one", 1, 1},
+		{"many", 50, 50},
+		{"negative", -1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var opts []RegistrarOption
+			if test.workers != 0 {
+				opts = append(opts, WithWorkers(test.workers))
+			}
+			registrar := NewRegistrar(newTestRegistry(t), opts...)
+			assert.Equal(t, test.expected, registrar.workers)
+		})
+	}
+}
+
+func TestRegistrar_RegisterAll(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t), WithWorkers(2))
+	defer registrar.Stop()
+
+	devices, summary := registrar.RegisterAll([]map[string]interface{}{
+		{
+			"mib":     "test-mib",
+			"version": "v2",
+			"agent":   "127.0.0.1:1",
+			"timeout": "100ms",
+		},
+		{
+			"mib":     "test-mib",
+			"version": "v2",
+			"agent":   "127.0.0.1:2",
+			"timeout": "100ms",
+			"discovery": map[string]interface{}{
+				"deferred":      true,
+				"retryInterval": "1h",
+			},
+		},
+		{
+			"mib":     "unknown-mib",
+			"version": "v2",
+			"agent":   "127.0.0.1:3",
+		},
+	})
+
+	// Only the deferred target registers devices; the failed targets do not
+	// prevent it from doing so.
+	assert.Len(t, devices, 2)
+	assert.Equal(t, 3, summary.Targets)
+	assert.Equal(t, 0, summary.Registered)
+	assert.Equal(t, 1, summary.Pending)
+	assert.Equal(t, 2, summary.Failed)
+	assert.Equal(t, 2, summary.Devices)
+	assert.Len(t, summary.Errors, 2)
+	assert.Equal(t, "127.0.0.1:1", summary.Errors[0].Agent)
+	assert.Equal(t, "127.0.0.1:3", summary.Errors[1].Agent)
+	assert.Contains(t, summary.Errors[1].Error(), "agent 127.0.0.1:3: configured MIB not found")
+}
+
+func TestRegistrar_Prepare(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	failed := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "127.0.0.1:1",
+		"timeout": "100ms",
+	}
+	deferred := map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "127.0.0.1:2",
+		"timeout": "100ms",
+		"discovery": map[string]interface{}{
+			"deferred":      true,
+			"retryInterval": "1h",
+		},
+	}
+
+	for _, data := range []map[string]interface{}{failed, deferred} {
+		protos, err := registrar.Prepare(data)
+		assert.NoError(t, err)
+		assert.Empty(t, protos)
+	}
+	assert.Nil(t, registrar.Summary())
+
+	// The first call to Register discovers all queued targets.
+	devices, err := registrar.Register(failed)
+	assert.NoError(t, err)
+	assert.Empty(t, devices)
+	assert.Len(t, registrar.Status(), 2)
+
+	devices, err = registrar.Register(deferred)
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	summary := registrar.Summary()
+	assert.NotNil(t, summary)
+	assert.Equal(t, 2, summary.Targets)
+	assert.Equal(t, 1, summary.Pending)
+	assert.Equal(t, 1, summary.Failed)
+
+	// Targets which were not queued are still registered on demand.
+	devices, err = registrar.Register(map[string]interface{}{
+		"mib":     "unknown-mib",
+		"version": "v2",
+		"agent":   "127.0.0.1:3",
+	})
+	assert.Error(t, err)
+	assert.Nil(t, devices)
+}
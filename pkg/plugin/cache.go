@@ -0,0 +1,194 @@
+package exp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// unsafeFilenameChars matches characters which are replaced when building cache
+// file names from agent and MIB names.
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// WithDiscoveryCache is a RegistrarOption which enables the on-disk discovery cache,
+// storing the discovery results for each agent and MIB in the given directory.
+//
+// When a target is registered and cached results exist for all of its MIBs, the
+// agent's sysObjectID is gotten first. If it matches the one the results were stored
+// for, or the agent can not be reached, the target's devices are registered from the
+// cache immediately and discovery is refreshed in the background. If it differs, the
+// cached results are invalidated and the target is discovered as usual. Cached
+// results are not used if the MIB definition has changed since they were stored,
+// and results of a partial discovery are never stored. Targets whose MIBs are
+// auto-detected do not use the cache.
+func WithDiscoveryCache(dir string) RegistrarOption {
+	return func(r *Registrar) {
+		r.cache = &discoveryCache{dir: dir}
+	}
+}
+
+// cacheEntry is the cached discovery result for a single agent and MIB.
+type cacheEntry struct {
+	Agent       string    `json:"agent"`
+	MIB         string    `json:"mib"`
+	MIBHash     string    `json:"mibHash"`
+	SysObjectID string    `json:"sysObjectID"`
+	Identity    string    `json:"identity,omitempty"`
+	Discovered  time.Time `json:"discovered"`
+	OIDs        []string  `json:"oids"`
+}
+
+// discoveryCache stores discovery results on disk, with one file per agent and MIB.
+type discoveryCache struct {
+	dir string
+}
+
+// path gets the path of the cache file for an agent and MIB.
+func (cache *discoveryCache) path(agent, mib string) string {
+	name := unsafeFilenameChars.ReplaceAllString(agent, "_") + "__" + unsafeFilenameChars.ReplaceAllString(mib, "_") + ".json"
+	return filepath.Join(cache.dir, name)
+}
+
+// load gets the cached entry for an agent and MIB. If there is no entry, or the
+// entry was stored for a different definition of the MIB, false is returned.
+func (cache *discoveryCache) load(agent string, mib *mibs.MIB) (*cacheEntry, bool) {
+	data, err := ioutil.ReadFile(cache.path(agent, mib.Name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(err).WithField("agent", agent).Warn("[snmp] failed to read discovery cache")
+		}
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		log.WithError(err).WithField("agent", agent).Warn("[snmp] failed to parse discovery cache; ignoring")
+		return nil, false
+	}
+	if entry.Agent != agent || entry.MIB != mib.Name {
+		return nil, false
+	}
+	if entry.MIBHash != mib.Hash() {
+		log.WithFields(log.Fields{
+			"agent": agent,
+			"mib":   mib.Name,
+		}).Info("[snmp] MIB definition changed; ignoring cached discovery results")
+		return nil, false
+	}
+	return &entry, true
+}
+
+// loadAll gets the cached supported OIDs for an agent across all of the given MIBs.
+// If any of the MIBs does not have a valid cache entry, false is returned.
+func (cache *discoveryCache) loadAll(agent string, targetMibs []*mibs.MIB) (map[string]struct{}, bool) {
+	supported := map[string]struct{}{}
+	for _, mib := range targetMibs {
+		entry, ok := cache.load(agent, mib)
+		if !ok {
+			return nil, false
+		}
+		for _, oid := range entry.OIDs {
+			supported[oid] = struct{}{}
+		}
+	}
+	return supported, true
+}
+
+// sysObjectID gets the agent sysObjectID recorded in the cache entries for the
+// given MIBs. If there are no cache entries, an empty string is returned.
+func (cache *discoveryCache) sysObjectID(agent string, targetMibs []*mibs.MIB) string {
+	for _, mib := range targetMibs {
+		if entry, ok := cache.load(agent, mib); ok {
+			return entry.SysObjectID
+		}
+	}
+	return ""
+}
+
+// identity gets the agent identity recorded in the cache entries for the given MIBs
+// (see SnmpIdentityConfiguration). If there are no cache entries, or they do not
+// record an identity, an empty string is returned.
+func (cache *discoveryCache) identity(agent string, targetMibs []*mibs.MIB) string {
+	for _, mib := range targetMibs {
+		if entry, ok := cache.load(agent, mib); ok {
+			return entry.Identity
+		}
+	}
+	return ""
+}
+
+// store caches the discovery results for an agent, with one entry per MIB. Each
+// entry holds the supported OIDs which fall within the MIB's roots or are defined
+// by the MIB, along with the agent's sysObjectID and identity.
+func (cache *discoveryCache) store(agent, sysObjectID, identity string, targetMibs []*mibs.MIB, supported map[string]struct{}) {
+	if err := os.MkdirAll(cache.dir, 0755); err != nil {
+		log.WithError(err).WithField("dir", cache.dir).Warn("[snmp] failed to create discovery cache directory")
+		return
+	}
+
+	for _, mib := range targetMibs {
+		defined := definedOids([]*mibs.MIB{mib}, supported)
+		roots := mib.Roots()
+
+		oids := []string{}
+		for oid := range supported {
+			if _, ok := defined[oid]; ok || withinAny(oid, roots) {
+				oids = append(oids, oid)
+			}
+		}
+		sort.Strings(oids)
+
+		data, err := json.MarshalIndent(&cacheEntry{
+			Agent:       agent,
+			MIB:         mib.Name,
+			MIBHash:     mib.Hash(),
+			SysObjectID: sysObjectID,
+			Identity:    identity,
+			Discovered:  time.Now(),
+			OIDs:        oids,
+		}, "", "  ")
+		if err != nil {
+			log.WithError(err).Warn("[snmp] failed to encode discovery cache entry")
+			continue
+		}
+
+		// Write the entry to a temporary file first, so a partially written
+		// entry is never read.
+		path := cache.path(agent, mib.Name)
+		tmp := path + ".tmp"
+		if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+			log.WithError(err).WithField("path", tmp).Warn("[snmp] failed to write discovery cache")
+			continue
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			log.WithError(err).WithField("path", path).Warn("[snmp] failed to write discovery cache")
+		}
+	}
+}
+
+// invalidate removes the cache entries for an agent and the given MIBs.
+func (cache *discoveryCache) invalidate(agent string, targetMibs []*mibs.MIB) {
+	for _, mib := range targetMibs {
+		if err := os.Remove(cache.path(agent, mib.Name)); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).WithField("agent", agent).Warn("[snmp] failed to invalidate discovery cache")
+		}
+	}
+}
+
+// withinAny checks whether the OID falls within any of the given subtrees.
+func withinAny(oid string, roots []string) bool {
+	for _, root := range roots {
+		if core.OidHasPrefix(oid, root) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package exp
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// newTestCache creates a discovery cache in a temporary directory.
+func newTestCache(t *testing.T) (*discoveryCache, func()) {
+	dir, err := ioutil.TempDir("", "snmp-discovery-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &discoveryCache{dir: dir}, func() { _ = os.RemoveAll(dir) }
+}
+
+func TestDiscoveryCache_StoreLoad(t *testing.T) {
+	cache, cleanup := newTestCache(t)
+	defer cleanup()
+
+	mib := newTestRegistry(t).Get("test-mib")
+	cache.store("udp://127.0.0.1:161", "1.3.6.1.4.1.9999", "800001", []*mibs.MIB{mib}, map[string]struct{}{
+		"1.2.3.4":   {},
+		"1.2.3.6.1": {},
+		"1.2.4.1":   {},
+	})
+
+	entry, ok := cache.load("udp://127.0.0.1:161", mib)
+	assert.True(t, ok)
+	assert.Equal(t, "test-mib", entry.MIB)
+	assert.Equal(t, "1.3.6.1.4.1.9999", entry.SysObjectID)
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.6.1"}, entry.OIDs)
+
+	supported, ok := cache.loadAll("udp://127.0.0.1:161", []*mibs.MIB{mib})
+	assert.True(t, ok)
+	assert.Len(t, supported, 2)
+	assert.Equal(t, "1.3.6.1.4.1.9999", cache.sysObjectID("udp://127.0.0.1:161", []*mibs.MIB{mib}))
+	assert.Equal(t, "800001", cache.identity("udp://127.0.0.1:161", []*mibs.MIB{mib}))
+
+	// There is nothing cached for other agents.
+	_, ok = cache.load("udp://127.0.0.1:162", mib)
+	assert.False(t, ok)
+
+	cache.invalidate("udp://127.0.0.1:161", []*mibs.MIB{mib})
+	_, ok = cache.load("udp://127.0.0.1:161", mib)
+	assert.False(t, ok)
+}
+
+func TestDiscoveryCache_MIBChanged(t *testing.T) {
+	cache, cleanup := newTestCache(t)
+	defer cleanup()
+
+	mib := newTestRegistry(t).Get("test-mib")
+	cache.store("localhost", "1.3.6.1.4.1.9999", "", []*mibs.MIB{mib}, map[string]struct{}{
+		"1.2.3.4": {},
+	})
+
+	changed := &mibs.MIB{
+		Name:    "test-mib",
+		RootOid: "1.2.3",
+		Devices: append(mib.Devices, &mibs.SnmpDevice{OID: "1.2.3.6"}),
+	}
+	_, ok := cache.load("localhost", changed)
+	assert.False(t, ok)
+	_, ok = cache.loadAll("localhost", []*mibs.MIB{mib, changed})
+	assert.False(t, ok)
+}
+
+func TestRegistrar_Register_FromCache(t *testing.T) {
+	cache, cleanup := newTestCache(t)
+	defer cleanup()
+	dir := cache.dir
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	config := map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	}
+
+	// The first registration discovers the devices and caches the results.
+	registrar := NewRegistrar(newTestRegistry(t), WithDiscoveryCache(dir))
+	devices, err := registrar.Register(config)
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	registrar.Stop()
+
+	// Once the agent is unreachable, devices are registered from the cache.
+	agent.Close()
+	registrar = NewRegistrar(newTestRegistry(t), WithDiscoveryCache(dir))
+	defer registrar.Stop()
+
+	devices, err = registrar.Register(config)
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	status := registrar.Status()
+	assert.Equal(t, core.TargetRegistered, status[0].State)
+	assert.True(t, status[0].Cached)
+	assert.Equal(t, 1, status[0].Supported)
+
+	target := devices[0].Data["target"].(*core.Target)
+	assert.True(t, target.IsSupported("1.2.3.4"))
+	assert.False(t, target.IsSupported("1.2.3.5"))
+}
+
+func TestRegistrar_Discover_IdentityChanged(t *testing.T) {
+	cache, cleanup := newTestCache(t)
+	defer cleanup()
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.1111"), testDeviceVar)
+	defer agent.Close()
+
+	registry := newTestRegistry(t)
+	mib := registry.Get("test-mib")
+	config := &core.SnmpTargetConfiguration{
+		MIB:       "test-mib",
+		Version:   "v2",
+		Agent:     agent.Addr(),
+		Community: "public",
+	}
+
+	// The cached results were stored for a different agent at the same address.
+	cache.store(config.Agent, "1.3.6.1.4.1.9999", "", []*mibs.MIB{mib}, map[string]struct{}{
+		"1.2.3.4": {},
+		"1.2.3.5": {},
+	})
+
+	registrar := NewRegistrar(registry)
+	registrar.cache = cache
+
+	result, err := registrar.discover(core.NewTarget(config, nil), []*mibs.MIB{mib})
+	assert.NoError(t, err)
+	assert.Len(t, result.supported, 1)
+
+	entry, ok := cache.load(config.Agent, mib)
+	assert.True(t, ok)
+	assert.Equal(t, "1.3.6.1.4.1.1111", entry.SysObjectID)
+	assert.Equal(t, []string{"1.2.3.4"}, entry.OIDs)
+}
+
+func TestRegistrar_Register_FromCache_SysObjectIDMatches(t *testing.T) {
+	cache, cleanup := newTestCache(t)
+	defer cleanup()
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+
+	registry := newTestRegistry(t)
+	cache.store(agent.Addr(), "1.3.6.1.4.1.9999", "", []*mibs.MIB{registry.Get("test-mib")}, map[string]struct{}{
+		"1.2.3.4": {},
+	})
+
+	registrar := NewRegistrar(registry, WithDiscoveryCache(cache.dir))
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+	assert.True(t, registrar.Status()[0].Cached)
+}
+
+func TestRegistrar_Register_FromCache_SysObjectIDChanged(t *testing.T) {
+	cache, cleanup := newTestCache(t)
+	defer cleanup()
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.1111"), testDeviceVar)
+	defer agent.Close()
+
+	registry := newTestRegistry(t)
+	mib := registry.Get("test-mib")
+
+	// The cached results were stored for a different agent at the same address.
+	cache.store(agent.Addr(), "1.3.6.1.4.1.9999", "", []*mibs.MIB{mib}, map[string]struct{}{
+		"1.2.3.4": {},
+		"1.2.3.5": {},
+	})
+
+	registrar := NewRegistrar(registry, WithDiscoveryCache(cache.dir))
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+
+	status := registrar.Status()
+	assert.False(t, status[0].Cached)
+	assert.Equal(t, 1, status[0].Supported)
+
+	// The stale entry was replaced by the results for the current agent.
+	entry, ok := cache.load(agent.Addr(), mib)
+	assert.True(t, ok)
+	assert.Equal(t, "1.3.6.1.4.1.1111", entry.SysObjectID)
+	assert.Equal(t, []string{"1.2.3.4"}, entry.OIDs)
+}
+
+func TestRegistrar_Reidentify(t *testing.T) {
+	cache, cleanup := newTestCache(t)
+	defer cleanup()
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: core.SnmpEngineIDOid, Type: gosnmp.OctetString, Value: []byte{0x80, 0x00, 0x01}})
+
+	registry := newTestRegistry(t)
+	mib := registry.Get("test-mib")
+	config, err := core.LoadTargetConfiguration(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+		"identity": map[string]interface{}{
+			"mode": "engine-id",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registrar := NewRegistrar(registry)
+	registrar.cache = cache
+
+	// The identity matches the agent's, so nothing changes.
+	target := core.NewTarget(config, []string{"test-mib"})
+	target.SetIdentity("800001")
+	cache.store(config.Agent, "1.3.6.1.4.1.9999", "800001", []*mibs.MIB{mib}, map[string]struct{}{"1.2.3.4": {}})
+	registrar.reidentify(target, []*mibs.MIB{mib})
+	assert.Equal(t, "", target.Status().RestartRequired)
+	_, ok := cache.load(config.Agent, mib)
+	assert.True(t, ok)
+
+	// The devices were registered with a stale identity (e.g. from the cache).
+	target = core.NewTarget(config, []string{"test-mib"})
+	target.SetIdentity("800002")
+	cache.store(config.Agent, "1.3.6.1.4.1.9999", "800002", []*mibs.MIB{mib}, map[string]struct{}{"1.2.3.4": {}})
+	registrar.reidentify(target, []*mibs.MIB{mib})
+	assert.Contains(t, target.Status().RestartRequired, `agent identity changed from "800002" to "800001"`)
+	_, ok = cache.load(config.Agent, mib)
+	assert.False(t, ok)
+}
+
+// testDeviceVar is the variable test agents serve for the first device of the test
+// MIB.
+var testDeviceVar = gosnmp.SnmpPDU{Name: "1.2.3.4", Type: gosnmp.Integer, Value: 20}
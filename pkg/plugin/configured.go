@@ -0,0 +1,57 @@
+package exp
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// configuredDevices is the device setup action which prepares the SNMP devices
+// defined in the Synse device configuration, rather than registered for a dynamic
+// registration target (see configureDevice).
+var configuredDevices = &sdk.DeviceAction{
+	Name: "load snmp target configuration for configured devices",
+	Filter: map[string][]string{
+		"type": {"*"},
+	},
+	Action: func(_ *sdk.Plugin, device *sdk.Device) error {
+		return configureDevice(device)
+	},
+}
+
+// configureDevice loads the SNMP target configuration of a device defined in the
+// Synse device configuration from the device's Data, so the device can be read like
+// a device registered for a dynamic registration target. The device Data holds the
+// same fields as a dynamic registration item (see core.LoadTargetConfiguration),
+// along with the device's "oid". Devices which already have a target configuration
+// (i.e. which were registered dynamically) and devices without an OID are left as
+// they are.
+func configureDevice(device *sdk.Device) error {
+	if _, exists := device.Data["target_cfg"]; exists {
+		return nil
+	}
+	if _, exists := device.Data["oid"]; !exists {
+		return nil
+	}
+
+	config, err := core.LoadTargetConfiguration(device.Data)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"id":   device.GetID(),
+			"info": device.Info,
+		}).Error("[snmp] invalid SNMP target configuration for configured device")
+		return fmt.Errorf("invalid SNMP target configuration for device %q (%s): %v", device.Info, device.GetID(), err)
+	}
+	// Make sure a client can be created for the configuration, so configuration
+	// errors (e.g. an unsupported version) surface at startup rather than on read.
+	c, err := core.NewClient(config)
+	if err != nil {
+		return fmt.Errorf("invalid SNMP target configuration for device %q (%s): %v", device.Info, device.GetID(), err)
+	}
+	c.Close()
+
+	device.Data["target_cfg"] = config
+	return nil
+}
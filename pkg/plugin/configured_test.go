@@ -0,0 +1,112 @@
+package exp
+
+import (
+	"os"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/handlers"
+)
+
+func TestConfigureDevice(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+
+	// A device as defined in the Synse device configuration.
+	device := &sdk.Device{
+		Info:    "door",
+		Type:    "status",
+		Handler: handlers.ReadOnly.Name,
+		Output:  "status",
+		Data: map[string]interface{}{
+			"agent":     agent.Addr(),
+			"version":   "v2",
+			"community": "public",
+			"timeout":   "100ms",
+			"mib":       "config",
+			"oid":       "1.2.3.4",
+			"enum": map[interface{}]interface{}{
+				20: "closed",
+			},
+		},
+	}
+	assert.NoError(t, SnmpDeviceDataValidator(device.Data))
+	assert.Equal(t, agent.Addr()+"-config:1.2.3.4", SnmpDeviceIdentifier(device.Data))
+
+	assert.NoError(t, configureDevice(device))
+	config, ok := device.Data["target_cfg"].(*core.SnmpTargetConfiguration)
+	assert.True(t, ok)
+	assert.Equal(t, agent.Addr(), config.Agent)
+	assert.Equal(t, "public", config.Community)
+
+	readings, err := handlers.ReadOnly.Read(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 1)
+	assert.Equal(t, "closed", readings[0].Value)
+}
+
+func TestConfigureDevice_Registered(t *testing.T) {
+	config := &core.SnmpTargetConfiguration{Agent: "localhost", Version: "v2"}
+	device := &sdk.Device{Data: map[string]interface{}{
+		"agent":      "localhost",
+		"mib":        "test-mib",
+		"oid":        "1.2.3.4",
+		"target_cfg": config,
+	}}
+
+	// Devices registered for a dynamic registration target are left as they are.
+	assert.NoError(t, configureDevice(device))
+	assert.Same(t, config, device.Data["target_cfg"])
+}
+
+func TestConfigureDevice_NoOid(t *testing.T) {
+	device := &sdk.Device{Data: map[string]interface{}{
+		"agent": "localhost",
+		"mib":   "test-mib",
+		"key":   "apparent-power",
+	}}
+
+	assert.NoError(t, configureDevice(device))
+	assert.NotContains(t, device.Data, "target_cfg")
+}
+
+func TestConfigureDevice_BadConfig(t *testing.T) {
+	device := &sdk.Device{
+		Info: "door",
+		Data: map[string]interface{}{
+			"agent":   "localhost",
+			"version": "v4",
+			"mib":     "config",
+			"oid":     "1.2.3.4",
+		},
+	}
+
+	err := configureDevice(device)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid SNMP target configuration for device "door"`)
+	assert.NotContains(t, device.Data, "target_cfg")
+}
+
+func TestNewSnmpBasePlugin_OptionalDynamicConfig(t *testing.T) {
+	if err := os.Setenv("PLUGIN_CONFIG", "./testdata/config.yml"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("PLUGIN_CONFIG")
+
+	plugin, err := NewSnmpBasePlugin(
+		&PluginMetadata{
+			Name:        "test",
+			Maintainer:  "test",
+			Description: "test",
+			VCS:         "test",
+		},
+		WithOptionalDynamicConfig(),
+	)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, plugin)
+}
@@ -0,0 +1,114 @@
+package exp
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/soniah/gosnmp"
+	"github.com/vapor-ware/synse-sdk/sdk/output"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// expectNumeric is the expected type of devices which do not declare an SMI type,
+// but whose output has a unit.
+const expectNumeric = "numeric"
+
+// TypeMismatch is a device whose value, as discovered on the agent, does not have
+// the type the device expects.
+type TypeMismatch struct {
+	// OID is the OID of the device.
+	OID string `json:"oid"`
+
+	// Expected is the SMI type the device declares, or "numeric" if the device
+	// does not declare one but its output has a unit.
+	Expected string `json:"expected"`
+
+	// Actual is the SMI type of the value the agent returned.
+	Actual string `json:"actual"`
+
+	// Action is the type mismatch policy which was applied to the device. If
+	// the policy is to decode the value, but there is no compatible decoder,
+	// the mismatch is only reported (core.TypeMismatchWarn).
+	Action string `json:"action"`
+
+	// Decoder is the decoder used to read the device, if its value is decoded.
+	Decoder string `json:"decoder,omitempty"`
+}
+
+// checkTypes finds the devices of the given MIBs whose discovered value does not
+// have the type the device expects. If more than one MIB defines a device with the
+// same OID, only the first definition is checked, as only it is loaded.
+func checkTypes(config *core.SnmpTargetConfiguration, targetMibs []*mibs.MIB, values core.DiscoveredValues) []*TypeMismatch {
+	var mismatches []*TypeMismatch
+	forEachDevice(targetMibs, values.OIDs(), func(mib *mibs.MIB, device *mibs.SnmpDevice) {
+		value, ok := values[device.OID]
+		if !ok {
+			return
+		}
+		expected := expectedType(device)
+		if expected == "" || conforms(expected, value.Type) {
+			return
+		}
+
+		mismatch := &TypeMismatch{
+			OID:      device.OID,
+			Expected: expected,
+			Actual:   core.SMIType(value.Type),
+			Action:   config.Discovery.TypeMismatch,
+		}
+		if mismatch.Action == core.TypeMismatchDecode {
+			mismatch.Decoder = selectDecoder(expected, value)
+			if mismatch.Decoder == "" {
+				mismatch.Action = core.TypeMismatchWarn
+			}
+		}
+		log.WithFields(log.Fields{
+			"agent":    config.Agent,
+			"mib":      mib.Name,
+			"oid":      mismatch.OID,
+			"expected": mismatch.Expected,
+			"actual":   mismatch.Actual,
+			"action":   mismatch.Action,
+			"decoder":  mismatch.Decoder,
+		}).Warn("[snmp] discovered value type does not match device type")
+		mismatches = append(mismatches, mismatch)
+	})
+	return mismatches
+}
+
+// expectedType gets the type a device expects its value to have. If the device does
+// not expect any particular type, an empty string is returned.
+func expectedType(device *mibs.SnmpDevice) string {
+	if device.SMIType != "" {
+		return device.SMIType
+	}
+	if o := output.Get(device.Output); o != nil && o.Unit != nil {
+		return expectNumeric
+	}
+	return ""
+}
+
+// conforms checks whether a value of the given PDU type has the expected type.
+func conforms(expected string, actual gosnmp.Asn1BER) bool {
+	if expected == expectNumeric {
+		return core.IsNumeric(actual)
+	}
+	return core.HasSMIType(actual, expected)
+}
+
+// selectDecoder gets a decoder which converts a value to the expected type. If the
+// value cannot be converted, an empty string is returned.
+func selectDecoder(expected string, value gosnmp.SnmpPDU) string {
+	switch {
+	case expected == expectNumeric || core.IsNumericSMIType(expected):
+		// Only numbers sent as strings can be read as numbers, so make sure
+		// the discovered value actually is one.
+		if value.Type == gosnmp.OctetString {
+			if _, err := core.DecodeValue(core.DecoderNumericString, value); err == nil {
+				return core.DecoderNumericString
+			}
+		}
+	case expected == core.SMIOctetString:
+		return core.DecoderString
+	}
+	return ""
+}
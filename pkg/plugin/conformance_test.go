@@ -0,0 +1,110 @@
+package exp
+
+import (
+	"testing"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// newConformanceMib creates a MIB with devices which expect a number, a Gauge32
+// and an OctetString.
+func newConformanceMib() *mibs.MIB {
+	return &mibs.MIB{
+		Name: "test-mib",
+		Devices: []*mibs.SnmpDevice{
+			{OID: "1.2.3.1", Type: "temperature", Handler: "read-only", Output: "temperature"},
+			{OID: "1.2.3.2", Type: "temperature", Handler: "read-only", Output: "temperature", SMIType: core.SMIGauge32},
+			{OID: "1.2.3.3", Type: "status", Handler: "read-only", Output: "string", SMIType: core.SMIOctetString},
+			{OID: "1.2.3.4", Type: "status", Handler: "read-only", Output: "string"},
+		},
+	}
+}
+
+// conformanceValues are discovered values which do not match the type of any of
+// the devices of the conformance MIB, except for the device with no expected type.
+var conformanceValues = core.DiscoveredValues{
+	"1.2.3.1": {Name: ".1.2.3.1", Type: gosnmp.OctetString, Value: []byte("23.5")},
+	"1.2.3.2": {Name: ".1.2.3.2", Type: gosnmp.Integer, Value: 20},
+	"1.2.3.3": {Name: ".1.2.3.3", Type: gosnmp.Integer, Value: 1},
+	"1.2.3.4": {Name: ".1.2.3.4", Type: gosnmp.Integer, Value: 1},
+}
+
+func TestNewDiscovery_Warn(t *testing.T) {
+	config := &core.SnmpTargetConfiguration{Agent: "localhost"}
+	config.Discovery.TypeMismatch = core.TypeMismatchWarn
+
+	result, err := newDiscovery(config, []*mibs.MIB{newConformanceMib()}, conformanceValues)
+	assert.NoError(t, err)
+	assert.Equal(t, []*TypeMismatch{
+		{OID: "1.2.3.1", Expected: "numeric", Actual: core.SMIOctetString, Action: core.TypeMismatchWarn},
+		{OID: "1.2.3.2", Expected: core.SMIGauge32, Actual: core.SMIInteger32, Action: core.TypeMismatchWarn},
+		{OID: "1.2.3.3", Expected: core.SMIOctetString, Actual: core.SMIInteger32, Action: core.TypeMismatchWarn},
+	}, result.mismatches)
+	assert.Len(t, result.supported, 4)
+	assert.Empty(t, result.decoders())
+}
+
+func TestNewDiscovery_Skip(t *testing.T) {
+	config := &core.SnmpTargetConfiguration{Agent: "localhost"}
+	config.Discovery.TypeMismatch = core.TypeMismatchSkip
+
+	result, err := newDiscovery(config, []*mibs.MIB{newConformanceMib()}, conformanceValues)
+	assert.NoError(t, err)
+	assert.Len(t, result.mismatches, 3)
+	assert.Equal(t, map[string]struct{}{"1.2.3.4": {}}, result.supported)
+	assert.Empty(t, result.decoders())
+}
+
+func TestNewDiscovery_Decode(t *testing.T) {
+	config := &core.SnmpTargetConfiguration{Agent: "localhost"}
+	config.Discovery.TypeMismatch = core.TypeMismatchDecode
+
+	result, err := newDiscovery(config, []*mibs.MIB{newConformanceMib()}, conformanceValues)
+	assert.NoError(t, err)
+	assert.Equal(t, []*TypeMismatch{
+		{OID: "1.2.3.1", Expected: "numeric", Actual: core.SMIOctetString, Action: core.TypeMismatchDecode, Decoder: core.DecoderNumericString},
+		{OID: "1.2.3.2", Expected: core.SMIGauge32, Actual: core.SMIInteger32, Action: core.TypeMismatchWarn},
+		{OID: "1.2.3.3", Expected: core.SMIOctetString, Actual: core.SMIInteger32, Action: core.TypeMismatchDecode, Decoder: core.DecoderString},
+	}, result.mismatches)
+	assert.Len(t, result.supported, 4)
+	assert.Equal(t, map[string]string{
+		"1.2.3.1": core.DecoderNumericString,
+		"1.2.3.3": core.DecoderString,
+	}, result.decoders())
+}
+
+func TestNewDiscovery_Unsigned32(t *testing.T) {
+	config := &core.SnmpTargetConfiguration{Agent: "localhost"}
+	config.Discovery.TypeMismatch = core.TypeMismatchSkip
+
+	// Unsigned32 values are sent with the Gauge32 tag.
+	mib := &mibs.MIB{
+		Name: "test-mib",
+		Devices: []*mibs.SnmpDevice{
+			{OID: "1.2.3.1", Type: "temperature", Handler: "read-only", Output: "temperature", SMIType: core.SMIUnsigned32},
+		},
+	}
+	values := core.DiscoveredValues{
+		"1.2.3.1": {Name: ".1.2.3.1", Type: gosnmp.Gauge32, Value: uint(20)},
+	}
+
+	result, err := newDiscovery(config, []*mibs.MIB{mib}, values)
+	assert.NoError(t, err)
+	assert.Empty(t, result.mismatches)
+	assert.Equal(t, map[string]struct{}{"1.2.3.1": {}}, result.supported)
+}
+
+func TestSelectDecoder(t *testing.T) {
+	number := gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("23.5")}
+	text := gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("n/a")}
+	integer := gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: 1}
+
+	assert.Equal(t, core.DecoderNumericString, selectDecoder(expectNumeric, number))
+	assert.Equal(t, core.DecoderNumericString, selectDecoder(core.SMIGauge32, number))
+	assert.Equal(t, "", selectDecoder(expectNumeric, text))
+	assert.Equal(t, core.DecoderString, selectDecoder(core.SMIOctetString, integer))
+	assert.Equal(t, "", selectDecoder(core.SMIIpAddress, integer))
+}
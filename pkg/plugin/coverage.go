@@ -0,0 +1,159 @@
+package exp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// WithCoverageReports is a RegistrarOption which writes the discovery coverage report
+// for each target (see CoverageReport) to the given directory as JSON, with one file
+// per agent. The report is rewritten whenever the target's devices are discovered.
+func WithCoverageReports(dir string) RegistrarOption {
+	return func(r *Registrar) {
+		r.coverageDir = dir
+	}
+}
+
+// CoverageReport describes how well the MIBs loaded for a target cover the OIDs its
+// agent supports. It shows which expected devices are missing from the agent, as well
+// as which objects the agent has that the MIBs do not define devices for.
+type CoverageReport struct {
+	// Agent is the configured agent address of the target.
+	Agent string `json:"agent"`
+
+	// MIBs are the names of the MIBs loaded for the target.
+	MIBs []string `json:"mibs"`
+
+	// Generated is the time the devices were discovered.
+	Generated time.Time `json:"generated"`
+
+	// Defined are the device OIDs defined by the MIBs.
+	Defined []string `json:"defined"`
+
+	// Supported are the defined device OIDs which the agent supports.
+	Supported []string `json:"supported"`
+
+	// Unsupported are the defined device OIDs which the agent does not support.
+	Unsupported []string `json:"unsupported"`
+
+	// Unexpected are the OIDs which were found on the agent during discovery,
+	// but which are not defined as devices by the MIBs.
+	Unexpected []string `json:"unexpected"`
+
+	// TypeMismatches are the supported devices whose value does not have the
+	// type the device expects.
+	TypeMismatches []*TypeMismatch `json:"typeMismatches"`
+
+	// ConditionNotMet are the OIDs of the devices which the agent supports, but
+	// whose condition is not met.
+	ConditionNotMet []string `json:"conditionNotMet"`
+
+	// MissingRequired are the OIDs of the required devices which the agent does
+	// not support.
+	MissingRequired []string `json:"missingRequired"`
+}
+
+// newCoverageReport creates the coverage report for the discovery result of an agent.
+func newCoverageReport(agent string, targetMibs []*mibs.MIB, result *discovery) *CoverageReport {
+	report := &CoverageReport{
+		Agent:           agent,
+		MIBs:            mibNames(targetMibs),
+		Generated:       time.Now(),
+		Defined:         []string{},
+		Supported:       []string{},
+		Unsupported:     []string{},
+		Unexpected:      []string{},
+		TypeMismatches:  []*TypeMismatch{},
+		ConditionNotMet: []string{},
+		MissingRequired: []string{},
+	}
+	values := result.values
+
+	// Devices whose OID is a pattern which matches none of the discovered OIDs
+	// are listed by their pattern.
+	forEachDefinition(targetMibs, func(_ *mibs.MIB, device *mibs.SnmpDevice) {
+		if !device.IsPattern() {
+			return
+		}
+		for oid := range values {
+			if _, ok := device.Match(oid); ok {
+				return
+			}
+		}
+		report.Defined = append(report.Defined, device.OID)
+		report.Unsupported = append(report.Unsupported, device.OID)
+	})
+
+	defined := definedOids(targetMibs, values.OIDs())
+	for oid := range defined {
+		report.Defined = append(report.Defined, oid)
+		if _, ok := values[oid]; ok {
+			report.Supported = append(report.Supported, oid)
+		} else {
+			report.Unsupported = append(report.Unsupported, oid)
+		}
+	}
+	for oid := range values {
+		if _, ok := defined[oid]; !ok {
+			report.Unexpected = append(report.Unexpected, oid)
+		}
+	}
+
+	for _, oids := range [][]string{report.Defined, report.Supported, report.Unsupported, report.Unexpected} {
+		sort.Slice(oids, func(i, j int) bool {
+			return core.CompareOids(oids[i], oids[j]) < 0
+		})
+	}
+	report.TypeMismatches = append(report.TypeMismatches, result.mismatches...)
+	report.ConditionNotMet = append(report.ConditionNotMet, result.unmet...)
+	report.MissingRequired = append(report.MissingRequired, result.missing...)
+	return report
+}
+
+// reportCoverage summarizes the coverage report for the discovery result of a
+// target and, if enabled, writes the report to the coverage report directory.
+func (r *Registrar) reportCoverage(target *core.Target, targetMibs []*mibs.MIB, result *discovery) {
+	report := newCoverageReport(target.Config.Agent, targetMibs, result)
+	log.WithFields(log.Fields{
+		"agent":       report.Agent,
+		"mibs":        report.MIBs,
+		"defined":     len(report.Defined),
+		"supported":   len(report.Supported),
+		"unsupported": len(report.Unsupported),
+		"unexpected":  len(report.Unexpected),
+		"mismatches":  len(report.TypeMismatches),
+		"unmet":       len(report.ConditionNotMet),
+		"missing":     len(report.MissingRequired),
+	}).Info("[snmp] discovery coverage for agent")
+	if len(report.Unsupported) != 0 {
+		log.WithFields(log.Fields{
+			"agent": report.Agent,
+			"oids":  report.Unsupported,
+		}).Debug("[snmp] MIB devices not supported by agent")
+	}
+
+	if r.coverageDir == "" {
+		return
+	}
+	if err := os.MkdirAll(r.coverageDir, 0755); err != nil {
+		log.WithError(err).WithField("dir", r.coverageDir).Warn("[snmp] failed to create coverage report directory")
+		return
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.WithError(err).Warn("[snmp] failed to encode coverage report")
+		return
+	}
+	path := filepath.Join(r.coverageDir, unsafeFilenameChars.ReplaceAllString(report.Agent, "_")+".json")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		log.WithError(err).WithField("path", path).Warn("[snmp] failed to write coverage report")
+	}
+}
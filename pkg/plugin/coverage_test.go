@@ -0,0 +1,72 @@
+package exp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+func TestNewCoverageReport(t *testing.T) {
+	mib := newTestRegistry(t).Get("test-mib")
+	config := &core.SnmpTargetConfiguration{Agent: "localhost"}
+	result, err := newDiscovery(config, []*mibs.MIB{mib}, core.DiscoveredValues{
+		"1.2.3.4":  {Name: ".1.2.3.4", Type: gosnmp.Integer, Value: 20},
+		"1.2.3.10": {Name: ".1.2.3.10", Type: gosnmp.Integer, Value: 1},
+		"1.2.3.9":  {Name: ".1.2.3.9", Type: gosnmp.Integer, Value: 1},
+	})
+	assert.NoError(t, err)
+
+	report := newCoverageReport("localhost", []*mibs.MIB{mib}, result)
+
+	assert.Equal(t, "localhost", report.Agent)
+	assert.Equal(t, []string{"test-mib"}, report.MIBs)
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.5"}, report.Defined)
+	assert.Equal(t, []string{"1.2.3.4"}, report.Supported)
+	assert.Equal(t, []string{"1.2.3.5"}, report.Unsupported)
+	assert.Equal(t, []string{"1.2.3.9", "1.2.3.10"}, report.Unexpected)
+	assert.Empty(t, report.TypeMismatches)
+	assert.Empty(t, report.ConditionNotMet)
+	assert.Empty(t, report.MissingRequired)
+}
+
+func TestRegistrar_Register_CoverageReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snmp-coverage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: "1.2.3.9", Type: gosnmp.Integer, Value: 1})
+
+	registrar := NewRegistrar(newTestRegistry(t), WithCoverageReports(dir))
+	defer registrar.Stop()
+
+	_, err = registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	})
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, unsafeFilenameChars.ReplaceAllString(agent.Addr(), "_")+".json"))
+	assert.NoError(t, err)
+
+	var report CoverageReport
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, agent.Addr(), report.Agent)
+	assert.Equal(t, []string{"1.2.3.4"}, report.Supported)
+	assert.Equal(t, []string{"1.2.3.5"}, report.Unsupported)
+	assert.Equal(t, []string{"1.2.3.9"}, report.Unexpected)
+}
@@ -0,0 +1,229 @@
+package exp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// ErrMissingRequired is the error for a target whose agent does not support all of
+// the required devices of its MIBs.
+var ErrMissingRequired = errors.New("agent does not support required devices")
+
+// discovery is the result of discovering the devices of a target.
+type discovery struct {
+	// values are the values the agent returned for the discovered OIDs.
+	values core.DiscoveredValues
+
+	// supported is the set of OIDs considered supported, which excludes the
+	// devices skipped due to type mismatches and the devices whose condition
+	// is not met.
+	supported map[string]struct{}
+
+	// mismatches are the devices whose value does not have the expected type.
+	mismatches []*TypeMismatch
+
+	// unmet are the OIDs of the devices whose condition is not met.
+	unmet []string
+
+	// missing are the OIDs of the required devices which are not supported.
+	missing []string
+
+	// partial is set if any of the walks ended early, in which case whether
+	// the agent supports the OIDs which were not reached is not known.
+	partial *core.PartialDiscoveryError
+}
+
+// discoverTarget discovers the devices of the given MIBs which the agent supports
+// (see discoverSupported) and checks the result against the devices' definitions
+// (see newDiscovery).
+func discoverTarget(c *core.Client, config *core.SnmpTargetConfiguration, targetMibs []*mibs.MIB) (*discovery, error) {
+	values, err := discoverSupported(c, config, targetMibs)
+	partial, isPartial := err.(*core.PartialDiscoveryError)
+	if err != nil && !isPartial {
+		return nil, err
+	}
+	d, err := newDiscovery(config, targetMibs, values)
+	if err != nil {
+		return nil, err
+	}
+	d.partial = partial
+	return d, nil
+}
+
+// keepUnwalked keeps the previously discovered state of the target for the OIDs
+// which were not reached by walks which ended early: those which the agent supported
+// before are still considered supported, rather than being retired.
+func (d *discovery) keepUnwalked(target *core.Target) {
+	if d.partial == nil {
+		return
+	}
+	for oid := range target.Supported() {
+		if d.partial.Unwalked(oid) {
+			d.supported[oid] = struct{}{}
+		}
+	}
+}
+
+// newDiscovery checks the discovered values of a target against the devices defined
+// by its MIBs. The target's type mismatch policy is applied to the devices whose
+// values do not have the expected type, and devices whose condition is not met are
+// not supported.
+//
+// If the agent does not support all of the required devices, an error wrapping
+// ErrMissingRequired is returned, unless the target's policy is to only mark the
+// target as degraded.
+func newDiscovery(config *core.SnmpTargetConfiguration, targetMibs []*mibs.MIB, values core.DiscoveredValues) (*discovery, error) {
+	d := &discovery{
+		values:     values,
+		supported:  values.OIDs(),
+		mismatches: checkTypes(config, targetMibs, values),
+		unmet:      checkConditions(config, targetMibs, values),
+	}
+	for _, mismatch := range d.mismatches {
+		if mismatch.Action == core.TypeMismatchSkip {
+			delete(d.supported, mismatch.OID)
+		}
+	}
+	unmet := map[string]struct{}{}
+	for _, oid := range d.unmet {
+		unmet[oid] = struct{}{}
+		delete(d.supported, oid)
+	}
+
+	forEachDefinition(targetMibs, func(_ *mibs.MIB, device *mibs.SnmpDevice) {
+		if !device.Required {
+			return
+		}
+		// A required device whose OID is a pattern must match at least one
+		// OID of the agent.
+		present := false
+		for oid := range values {
+			if _, ok := device.Match(oid); ok || oid == device.OID {
+				_, isSupported := d.supported[oid]
+				_, isUnmet := unmet[oid]
+				if isSupported || isUnmet {
+					present = true
+					break
+				}
+			}
+		}
+		if !present {
+			d.missing = append(d.missing, device.OID)
+		}
+	})
+	if len(d.missing) != 0 {
+		sort.Strings(d.missing)
+		log.WithFields(log.Fields{
+			"agent":   config.Agent,
+			"missing": d.missing,
+			"policy":  config.Discovery.MissingRequired,
+		}).Warn("[snmp] agent does not support required devices")
+
+		if config.Discovery.MissingRequired != core.MissingRequiredDegrade {
+			return nil, fmt.Errorf("%w: %s", ErrMissingRequired, strings.Join(d.missing, ", "))
+		}
+	}
+	return d, nil
+}
+
+// decoders gets the decoders for the devices whose values are decoded, keyed by OID.
+func (d *discovery) decoders() map[string]string {
+	decoders := map[string]string{}
+	for _, mismatch := range d.mismatches {
+		if mismatch.Decoder != "" {
+			decoders[mismatch.OID] = mismatch.Decoder
+		}
+	}
+	return decoders
+}
+
+// checkConditions finds the devices of the given MIBs which the agent supports, but
+// whose condition is not met. A condition is not met if the agent does not support
+// the condition's OID.
+func checkConditions(config *core.SnmpTargetConfiguration, targetMibs []*mibs.MIB, values core.DiscoveredValues) []string {
+	var unmet []string
+	forEachDevice(targetMibs, values.OIDs(), func(mib *mibs.MIB, device *mibs.SnmpDevice) {
+		if device.Condition == nil {
+			return
+		}
+		if _, ok := values[device.OID]; !ok {
+			return
+		}
+
+		var value interface{}
+		pdu, ok := values[core.NormalizeOid(device.Condition.OID)]
+		if ok {
+			v, err := core.DecodeValue("", pdu)
+			ok = err == nil && device.Condition.Matches(v)
+			value = v
+		}
+		if !ok {
+			log.WithFields(log.Fields{
+				"agent":     config.Agent,
+				"mib":       mib.Name,
+				"oid":       device.OID,
+				"condition": device.Condition,
+				"value":     value,
+			}).Info("[snmp] device condition not met; will not load")
+			unmet = append(unmet, device.OID)
+		}
+	})
+	return unmet
+}
+
+// conditionOids gets the OIDs of the conditions of the devices of the given MIBs.
+func conditionOids(targetMibs []*mibs.MIB) []string {
+	var oids []string
+	seen := map[string]struct{}{}
+	forEachDefinition(targetMibs, func(_ *mibs.MIB, device *mibs.SnmpDevice) {
+		if device.Condition == nil {
+			return
+		}
+		oid := core.NormalizeOid(device.Condition.OID)
+		if _, exists := seen[oid]; !exists {
+			seen[oid] = struct{}{}
+			oids = append(oids, oid)
+		}
+	})
+	return oids
+}
+
+// forEachDevice calls the given function for each device of the given MIBs, with the
+// devices whose OID is a pattern expanded for the given OIDs (see ExpandedDevices).
+// If more than one MIB defines a device with the same OID, the function is only
+// called for the first definition, as only it is loaded.
+func forEachDevice(targetMibs []*mibs.MIB, oids map[string]struct{}, fn func(mib *mibs.MIB, device *mibs.SnmpDevice)) {
+	seen := map[string]struct{}{}
+	for _, mib := range targetMibs {
+		for _, device := range mib.ExpandedDevices(oids) {
+			if _, exists := seen[device.OID]; exists {
+				continue
+			}
+			seen[device.OID] = struct{}{}
+			fn(mib, device)
+		}
+	}
+}
+
+// forEachDefinition calls the given function for each device definition of the
+// given MIBs, without expanding the devices whose OID is a pattern. If more than one
+// MIB defines a device with the same OID, the function is only called for the first
+// definition.
+func forEachDefinition(targetMibs []*mibs.MIB, fn func(mib *mibs.MIB, device *mibs.SnmpDevice)) {
+	seen := map[string]struct{}{}
+	for _, mib := range targetMibs {
+		for _, device := range mib.ResolvedDevices() {
+			if _, exists := seen[device.OID]; exists {
+				continue
+			}
+			seen[device.OID] = struct{}{}
+			fn(mib, device)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package exp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// newConditionMib creates a MIB with a required device and a device which is only
+// present if a sensor is installed.
+func newConditionMib() *mibs.MIB {
+	return &mibs.MIB{
+		Name:    "test-mib",
+		RootOid: "1.2.3",
+		Devices: []*mibs.SnmpDevice{
+			{OID: "1.2.3.4", Info: "required device", Type: "temperature", Handler: "read-only", Output: "temperature", Required: true},
+			{OID: "1.2.3.5", Info: "conditional device", Type: "temperature", Handler: "read-only", Output: "temperature", Condition: &mibs.Condition{
+				OID:    "1.2.9.1",
+				Values: []interface{}{1},
+			}},
+		},
+	}
+}
+
+func TestNewDiscovery_Condition(t *testing.T) {
+	config := &core.SnmpTargetConfiguration{Agent: "localhost"}
+	values := core.DiscoveredValues{
+		"1.2.3.4": {Name: ".1.2.3.4", Type: gosnmp.Integer, Value: 20},
+		"1.2.3.5": {Name: ".1.2.3.5", Type: gosnmp.Integer, Value: 20},
+		"1.2.9.1": {Name: ".1.2.9.1", Type: gosnmp.Integer, Value: 1},
+	}
+
+	result, err := newDiscovery(config, []*mibs.MIB{newConditionMib()}, values)
+	assert.NoError(t, err)
+	assert.Empty(t, result.unmet)
+	assert.Contains(t, result.supported, "1.2.3.5")
+
+	// The sensor is not installed.
+	values["1.2.9.1"] = gosnmp.SnmpPDU{Name: ".1.2.9.1", Type: gosnmp.Integer, Value: 2}
+	result, err = newDiscovery(config, []*mibs.MIB{newConditionMib()}, values)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.5"}, result.unmet)
+	assert.NotContains(t, result.supported, "1.2.3.5")
+
+	// The agent does not have the sensor flag.
+	delete(values, "1.2.9.1")
+	result, err = newDiscovery(config, []*mibs.MIB{newConditionMib()}, values)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.5"}, result.unmet)
+}
+
+func TestNewDiscovery_MissingRequired(t *testing.T) {
+	config := &core.SnmpTargetConfiguration{Agent: "localhost"}
+	config.Discovery.MissingRequired = core.MissingRequiredFail
+	values := core.DiscoveredValues{
+		"1.2.3.5": {Name: ".1.2.3.5", Type: gosnmp.Integer, Value: 20},
+		"1.2.9.1": {Name: ".1.2.9.1", Type: gosnmp.Integer, Value: 1},
+	}
+
+	result, err := newDiscovery(config, []*mibs.MIB{newConditionMib()}, values)
+	assert.True(t, errors.Is(err, ErrMissingRequired))
+	assert.EqualError(t, err, "agent does not support required devices: 1.2.3.4")
+	assert.Nil(t, result)
+
+	config.Discovery.MissingRequired = core.MissingRequiredDegrade
+	result, err = newDiscovery(config, []*mibs.MIB{newConditionMib()}, values)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.4"}, result.missing)
+}
+
+func TestDiscovery_KeepUnwalked(t *testing.T) {
+	target := core.NewTarget(&core.SnmpTargetConfiguration{Agent: "localhost"}, []string{"test-mib"})
+	target.Discovered(map[string]struct{}{
+		"1.2.3.4": {},
+		"1.2.3.5": {},
+		"1.2.3.6": {},
+	})
+
+	// The walk ended after 1.2.3.4, so 1.2.3.5 and 1.2.3.6 were not reached and
+	// keep their previous state, while 1.2.3.4 is no longer supported.
+	d := &discovery{
+		supported: map[string]struct{}{"1.2.3.2": {}},
+		partial: &core.PartialDiscoveryError{Walks: []*core.PartialWalkError{
+			{RootOid: "1.2.3", LastOid: "1.2.3.4"},
+		}},
+	}
+	d.keepUnwalked(target)
+	assert.Equal(t, map[string]struct{}{
+		"1.2.3.2": {},
+		"1.2.3.5": {},
+		"1.2.3.6": {},
+	}, d.supported)
+
+	// Complete discoveries are used as they are.
+	d = &discovery{supported: map[string]struct{}{"1.2.3.2": {}}}
+	d.keepUnwalked(target)
+	assert.Equal(t, map[string]struct{}{"1.2.3.2": {}}, d.supported)
+}
+
+func TestRegistrar_Register_Condition(t *testing.T) {
+	registry := mibs.NewRegistry()
+	if err := registry.Register(newConditionMib()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sensor flag is outside of the MIB root, so it is fetched directly.
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(
+		gosnmp.SnmpPDU{Name: "1.2.3.5", Type: gosnmp.Integer, Value: 20},
+		gosnmp.SnmpPDU{Name: "1.2.9.1", Type: gosnmp.Integer, Value: 2},
+	)
+
+	registrar := NewRegistrar(registry)
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.Equal(t, "1.2.3.4", devices[0].Data["oid"])
+}
+
+func TestRegistrar_Register_MissingRequired(t *testing.T) {
+	registry := mibs.NewRegistry()
+	if err := registry.Register(newConditionMib()); err != nil {
+		t.Fatal(err)
+	}
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Remove("1.2.3.4")
+	agent.Set(gosnmp.SnmpPDU{Name: "1.2.3.6", Type: gosnmp.Integer, Value: 20})
+
+	config := map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	}
+
+	registrar := NewRegistrar(registry)
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(config)
+	assert.True(t, errors.Is(err, ErrMissingRequired))
+	assert.Nil(t, devices)
+	assert.Equal(t, core.TargetFailed, registrar.Status()[0].State)
+
+	config["discovery"] = map[string]interface{}{"missingRequired": "degrade"}
+	devices, err = registrar.Register(config)
+	assert.NoError(t, err)
+	assert.Empty(t, devices)
+
+	status := registrar.Status()[1]
+	assert.Equal(t, core.TargetDegraded, status.State)
+	assert.Equal(t, []string{"1.2.3.4"}, status.Missing)
+}
+
+func TestRegistrar_Register_Pattern(t *testing.T) {
+	registry := mibs.NewRegistry()
+	if err := registry.Register(&mibs.MIB{
+		Name:    "test-mib",
+		RootOid: "1.2.3",
+		Devices: []*mibs.SnmpDevice{
+			{OID: "1.2.3.1.*", Info: "outlet {1}", Type: "temperature", Handler: "read-only", Output: "temperature"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(
+		gosnmp.SnmpPDU{Name: "1.2.3.1.2", Type: gosnmp.Integer, Value: 21},
+		gosnmp.SnmpPDU{Name: "1.2.3.1.1", Type: gosnmp.Integer, Value: 20},
+		gosnmp.SnmpPDU{Name: "1.2.3.1.1.1", Type: gosnmp.Integer, Value: 22},
+	)
+
+	registrar := NewRegistrar(registry)
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	assert.Equal(t, "outlet 1", devices[0].Info)
+	assert.Equal(t, "1.2.3.1.1", devices[0].Data["oid"])
+	assert.Equal(t, "1", devices[0].Data["index"])
+	assert.Equal(t, "outlet 2", devices[1].Info)
+	assert.Equal(t, "1.2.3.1.2", devices[1].Data["oid"])
+	assert.Equal(t, "2", devices[1].Data["index"])
+}
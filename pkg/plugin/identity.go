@@ -0,0 +1,72 @@
+package exp
+
+import (
+	"fmt"
+
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// resolveIdentity gets the identity of a target's agent, as given by the target's
+// identity mode (see core.SnmpIdentityConfiguration). The client is only used for
+// identity modes which require getting the identity from the agent.
+func resolveIdentity(c *core.Client, config *core.SnmpTargetConfiguration) (string, error) {
+	switch config.Identity.Mode {
+	case core.IdentityCanonical:
+		return core.CanonicalAgent(config.Agent)
+
+	case core.IdentityEngineID:
+		id, err := c.GetEngineID()
+		if err != nil {
+			return "", fmt.Errorf("unable to get snmpEngineID of agent %s: %v", config.Agent, err)
+		}
+		return id, nil
+
+	case core.IdentitySysNameSerial:
+		name, err := c.GetString(core.SysNameOid)
+		if err != nil {
+			return "", fmt.Errorf("unable to get sysName of agent %s: %v", config.Agent, err)
+		}
+		serial, err := c.GetString(config.Identity.SerialOid)
+		if err != nil {
+			return "", fmt.Errorf("unable to get serial number of agent %s: %v", config.Agent, err)
+		}
+		return name + "/" + serial, nil
+	}
+	return config.Agent, nil
+}
+
+// identify sets the ID of a device of the target, rendered from the target's device
+// ID template, in the device data (see SnmpDeviceIdentifier). If a previous device
+// ID is aliased to the device's ID, it is set as the device's alias, so the device
+// can still be looked up by its previous ID. A device can only have one alias, so
+// aliasing a previous ID to a device which already has an alias defined by its MIB
+// is an error.
+func identify(target *core.Target, device *sdk.Device) error {
+	oid, exists := device.Data["oid"]
+	if !exists {
+		oid = device.Data["key"]
+	}
+	id, err := target.Config.Identity.DeviceID(core.IDContext{
+		Identity: target.Identity(),
+		Agent:    target.Config.Agent,
+		MIB:      fmt.Sprint(device.Data["mib"]),
+		OID:      fmt.Sprint(oid),
+	})
+	if err != nil {
+		return err
+	}
+	device.Data["device_id"] = id
+
+	for previous, current := range target.Config.Identity.Aliases {
+		if current != id {
+			continue
+		}
+		if device.Alias != "" && device.Alias != previous {
+			return fmt.Errorf("identity.aliases: device %s already has alias %q defined by its MIB, can not alias previous device ID %q", id, device.Alias, previous)
+		}
+		device.Alias = previous
+		break
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package exp
+
+import (
+	"testing"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+)
+
+// newIdentityConfig creates a target configuration for a test agent with the given
+// identity mode.
+func newIdentityConfig(t *testing.T, agent string, mode string) *core.SnmpTargetConfiguration {
+	config, err := core.LoadTargetConfiguration(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent,
+		"community": "public",
+		"timeout":   "100ms",
+		"identity": map[string]interface{}{
+			"mode": mode,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestResolveIdentity(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: core.SnmpEngineIDOid, Type: gosnmp.OctetString, Value: []byte{0x80, 0x00, 0x01}})
+	agent.Set(gosnmp.SnmpPDU{Name: core.SysNameOid, Type: gosnmp.OctetString, Value: []byte("pdu-1")})
+	agent.Set(gosnmp.SnmpPDU{Name: core.EntPhysicalSerialNumOid, Type: gosnmp.OctetString, Value: []byte("SN123")})
+
+	tests := []struct {
+		mode     string
+		identity string
+	}{
+		{core.IdentityAgent, agent.Addr()},
+		{core.IdentityCanonical, "udp://" + agent.Addr()},
+		{core.IdentityEngineID, "800001"},
+		{core.IdentitySysNameSerial, "pdu-1/SN123"},
+	}
+
+	for _, test := range tests {
+		config := newIdentityConfig(t, agent.Addr(), test.mode)
+		c, err := core.NewClient(config)
+		assert.NoError(t, err)
+
+		identity, err := resolveIdentity(c, config)
+		assert.NoError(t, err, test.mode)
+		assert.Equal(t, test.identity, identity, test.mode)
+		c.Close()
+	}
+}
+
+func TestResolveIdentity_Error(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+
+	config := newIdentityConfig(t, agent.Addr(), core.IdentitySysNameSerial)
+	c, err := core.NewClient(config)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, err = resolveIdentity(c, config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to get sysName of agent "+agent.Addr())
+}
+
+func TestIdentify(t *testing.T) {
+	config := newIdentityConfig(t, "localhost", core.IdentityEngineID)
+	config.Identity.Template = "{{.Identity}}/{{.MIB}}/{{.OID}}"
+	config.Identity.Aliases = map[string]string{
+		"localhost-test-mib:1.2.3.4": "800001/test-mib/1.2.3.4",
+	}
+	target := core.NewTarget(config, []string{"test-mib"})
+	target.SetIdentity("800001")
+
+	device := &sdk.Device{Data: map[string]interface{}{
+		"oid":   "1.2.3.4",
+		"mib":   "test-mib",
+		"agent": "localhost",
+	}}
+	assert.NoError(t, identify(target, device))
+	assert.Equal(t, "800001/test-mib/1.2.3.4", SnmpDeviceIdentifier(device.Data))
+	assert.Equal(t, "localhost-test-mib:1.2.3.4", device.Alias)
+
+	// Computed and status devices are identified by their key.
+	device = &sdk.Device{Data: map[string]interface{}{
+		"key":   "status",
+		"mib":   "agent",
+		"agent": "localhost",
+	}}
+	assert.NoError(t, identify(target, device))
+	assert.Equal(t, "800001/agent/status", SnmpDeviceIdentifier(device.Data))
+	assert.Equal(t, "", device.Alias)
+}
+
+func TestIdentify_ExistingAlias(t *testing.T) {
+	config := newIdentityConfig(t, "localhost", core.IdentityAgent)
+	config.Identity.Aliases = map[string]string{
+		"old-id": "localhost-test-mib:1.2.3.4",
+	}
+	target := core.NewTarget(config, []string{"test-mib"})
+	target.SetIdentity("localhost")
+
+	device := &sdk.Device{
+		Alias: "rack-1-inlet",
+		Data: map[string]interface{}{
+			"oid":   "1.2.3.4",
+			"mib":   "test-mib",
+			"agent": "localhost",
+		},
+	}
+	err := identify(target, device)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `already has alias "rack-1-inlet"`)
+	assert.Contains(t, err.Error(), `"old-id"`)
+	assert.Equal(t, "rack-1-inlet", device.Alias)
+
+	// Aliasing the same ID the MIB defines is not a conflict.
+	config.Identity.Aliases = map[string]string{
+		"rack-1-inlet": "localhost-test-mib:1.2.3.4",
+	}
+	assert.NoError(t, identify(target, device))
+	assert.Equal(t, "rack-1-inlet", device.Alias)
+}
@@ -2,8 +2,8 @@ package exp
 
 import (
 	"fmt"
+	"strings"
 
-	log "github.com/sirupsen/logrus"
 	"github.com/vapor-ware/synse-sdk/sdk"
 	"github.com/vapor-ware/synse-snmp-base/pkg/core"
 	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
@@ -17,8 +17,10 @@ import (
 //
 // The expectation is that each device should be uniquely identifiable using a
 // combination of the SNMP device's OID and MIB name. As such, those fields are
-// expected in the device Data. If they are not present, the plugin will panic
-// and terminate.
+// expected in the device Data. If they are not present, the device fails
+// validation (see SnmpDeviceDataValidator) before it is identified, so it is not
+// registered. Computed devices, which have no OID of their own, are identified
+// by their key instead.
 //
 // Additionally, since there may be multiple SNMP-enabled servers configured
 // which use the same MIB, the id generation needs to take the configured host/port
@@ -27,73 +29,91 @@ import (
 // Generally, it is not the responsibility of the plugin writer to ensure that
 // info exists per device because the SNMP base plugin provides utility functions
 // which automatically fill this information in when building Synse devices.
+//
+// Devices registered by the Registrar carry the ID rendered from their target's
+// identity configuration (see core.SnmpIdentityConfiguration) as "device_id" in the
+// device Data, which takes precedence. With the default identity configuration, it
+// is the same as the ID generated from the agent, MIB and OID.
 func SnmpDeviceIdentifier(data map[string]interface{}) string {
+	// Devices which can not be identified are rejected by SnmpDeviceDataValidator
+	// before they are identified, so the error does not need to be handled here.
+	id, _ := deviceIdentifier(data)
+	return id
+}
+
+// SnmpDeviceDataValidator is the device data validator used by the SDK to validate
+// the Data of each device before the device is identified (see SnmpDeviceIdentifier).
+//
+// It checks that the device can be identified, i.e. that its Data holds the "oid"
+// (or "key"), "mib" and "agent" fields. This matters for devices defined in the
+// device configuration, since those are not built by the SNMP base plugin. A device
+// which can not be identified fails registration with an error describing the
+// device and where it was defined, rather than terminating the plugin.
+func SnmpDeviceDataValidator(data map[string]interface{}) error {
+	_, err := deviceIdentifier(data)
+	return err
+}
+
+// deviceIdentifier generates the ID of a device from its Data (see
+// SnmpDeviceIdentifier), or returns an error if the Data does not hold the fields
+// needed to identify the device.
+func deviceIdentifier(data map[string]interface{}) (string, error) {
+	if id, ok := data["device_id"].(string); ok && id != "" {
+		return id, nil
+	}
 	oid, exists := data["oid"]
 	if !exists {
-		panic("unable to generate device ID: 'oid' not found in device data")
+		oid, exists = data["key"]
+	}
+	if !exists {
+		return "", deviceDataError(data, "oid")
 	}
 	mibName, exists := data["mib"]
 	if !exists {
-		panic("unable to generate device ID: 'mib' not found in device data")
+		return "", deviceDataError(data, "mib")
 	}
 	agent, exists := data["agent"]
 	if !exists {
-		panic("unable to generate device ID: 'agent' not found in device data")
+		return "", deviceDataError(data, "agent")
 	}
 
-	return fmt.Sprintf("%v-%s:%s", agent, mibName, oid)
+	return fmt.Sprintf("%v-%s:%s", agent, mibName, oid), nil
+}
+
+// deviceDataError creates the error for a device whose Data is missing a field
+// needed to identify it. The error describes the device by the identifying fields
+// it does hold, along with where the device was defined. Other fields are left out,
+// since they may hold sensitive configuration (e.g. SNMPv3 passphrases).
+func deviceDataError(data map[string]interface{}, field string) error {
+	var fields []string
+	for _, key := range []string{"oid", "key", "mib", "agent"} {
+		if value, exists := data[key]; exists {
+			fields = append(fields, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+	device := "{" + strings.Join(fields, ", ") + "}"
+
+	source := "device configuration"
+	if target, ok := data["target"].(*core.Target); ok {
+		source = fmt.Sprintf("dynamic registration for agent %s", target.Config.Agent)
+	}
+	return fmt.Errorf("unable to generate device ID for SNMP device %s from %s: '%s' not found in device data", device, source, field)
 }
 
 // SnmpDeviceRegistrar is the dynamic registration function used by the SDK to
 // build devices at runtime.
 //
 // This function is defined for the base SNMP plugin and is subsequently used
-// by all plugins which use the base.
-//
-// It loads all devices for the specified MIB and caches the SNMP configuration
-// for each device. This allows each device to create a new client on demand using
-// this pre-loaded configuration.
+// by all plugins which use the base. It loads devices for the MIBs registered
+// with the default MIB registry. See NewSnmpDeviceRegistrar for details.
 func SnmpDeviceRegistrar(data map[string]interface{}) ([]*sdk.Device, error) {
-	// Load the data into a configurations struct.
-	config, err := core.LoadTargetConfiguration(data)
-	if err != nil {
-		return nil, err
-	}
-
-	// Verify that the configured agent as a MIB name specified. This is
-	// required, as it determines which devices will be loaded for the agent.
-	if config.MIB == "" {
-		return nil, fmt.Errorf("invalid configuration: no MIB specified for agent %s", config.Agent)
-	}
-
-	// Get the specified MIB and load its devices for the agent.
-	mib := mibs.Get(data["mib"].(string))
-	if mib == nil {
-		log.WithFields(log.Fields{
-			"mib": data["mib"],
-		}).Error("[snmp] specified MIB is not registered with the plugin")
-		return nil, fmt.Errorf("configured MIB not found")
-	}
-
-	// Create an SNMP client for the configured target.
-	c, err := core.NewClient(config)
-	if err != nil {
-		return nil, err
-	}
-	if err := c.Connect(); err != nil {
-		return nil, err
-	}
-	defer c.Close()
-
-	supportedDevices, err := c.GetSupportedDevices(mib.RootOid)
-	if err != nil {
-		return nil, err
-	}
+	return NewSnmpDeviceRegistrar(mibs.DefaultRegistry)(data)
+}
 
-	d, err := mib.LoadDevices(config, supportedDevices)
-	if err != nil {
-		log.WithError(err).Error("[snmp] failed to load devices from MIB")
-		return nil, err
-	}
-	return d, nil
+// NewSnmpDeviceRegistrar creates a dynamic registration function which loads
+// devices for the MIBs registered with the given MIB registry.
+//
+// See Registrar.Register for details.
+func NewSnmpDeviceRegistrar(registry *mibs.Registry) sdk.DynamicDeviceRegistrar {
+	return NewRegistrar(registry).Register
 }
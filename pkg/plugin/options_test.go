@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
 )
 
 func TestSnmpDeviceIdentifier(t *testing.T) {
@@ -17,15 +19,28 @@ func TestSnmpDeviceIdentifier(t *testing.T) {
 	assert.Equal(t, "localhost:1234-test-mib:1.2.3.4.5.6", identifier)
 }
 
+func TestSnmpDeviceIdentifier_Computed(t *testing.T) {
+	data := map[string]interface{}{
+		"key":   "apparent-power",
+		"mib":   "test-mib",
+		"agent": "localhost:1234",
+	}
+
+	identifier := SnmpDeviceIdentifier(data)
+	assert.Equal(t, "localhost:1234-test-mib:apparent-power", identifier)
+}
+
 func TestSnmpDeviceIdentifier_NoOid(t *testing.T) {
 	data := map[string]interface{}{
 		"mib":   "test-mib",
 		"agent": "localhost:1234",
 	}
 
-	assert.Panics(t, func() {
+	assert.NotPanics(t, func() {
 		_ = SnmpDeviceIdentifier(data)
 	})
+	assert.EqualError(t, SnmpDeviceDataValidator(data),
+		"unable to generate device ID for SNMP device {mib=test-mib, agent=localhost:1234} from device configuration: 'oid' not found in device data")
 }
 
 func TestSnmpDeviceIdentifier_NoMib(t *testing.T) {
@@ -34,9 +49,11 @@ func TestSnmpDeviceIdentifier_NoMib(t *testing.T) {
 		"agent": "localhost:1234",
 	}
 
-	assert.Panics(t, func() {
+	assert.NotPanics(t, func() {
 		_ = SnmpDeviceIdentifier(data)
 	})
+	assert.EqualError(t, SnmpDeviceDataValidator(data),
+		"unable to generate device ID for SNMP device {oid=1.2.3.4.5.6, agent=localhost:1234} from device configuration: 'mib' not found in device data")
 }
 
 func TestSnmpDeviceIdentifier_NoAgent(t *testing.T) {
@@ -45,9 +62,40 @@ func TestSnmpDeviceIdentifier_NoAgent(t *testing.T) {
 		"mib": "test-mib",
 	}
 
-	assert.Panics(t, func() {
+	assert.NotPanics(t, func() {
 		_ = SnmpDeviceIdentifier(data)
 	})
+	assert.EqualError(t, SnmpDeviceDataValidator(data),
+		"unable to generate device ID for SNMP device {oid=1.2.3.4.5.6, mib=test-mib} from device configuration: 'agent' not found in device data")
+}
+
+func TestSnmpDeviceDataValidator(t *testing.T) {
+	assert.NoError(t, SnmpDeviceDataValidator(map[string]interface{}{
+		"oid":   "1.2.3.4.5.6",
+		"mib":   "test-mib",
+		"agent": "localhost:1234",
+	}))
+	assert.NoError(t, SnmpDeviceDataValidator(map[string]interface{}{
+		"device_id": "800001-test-mib:1.2.3.4.5.6",
+	}))
+}
+
+func TestSnmpDeviceDataValidator_Dynamic(t *testing.T) {
+	config, err := core.LoadTargetConfiguration(map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "localhost:1234",
+	})
+	assert.NoError(t, err)
+
+	err = SnmpDeviceDataValidator(map[string]interface{}{
+		"oid":        "1.2.3.4.5.6",
+		"agent":      "localhost:1234",
+		"target_cfg": config,
+		"target":     core.NewTarget(config, []string{"test-mib"}),
+	})
+	assert.EqualError(t, err,
+		"unable to generate device ID for SNMP device {oid=1.2.3.4.5.6, agent=localhost:1234} from dynamic registration for agent localhost:1234: 'mib' not found in device data")
 }
 
 // TODO (etd): re-implement - need to mock out the snmp client
@@ -141,3 +189,40 @@ func TestSnmpDeviceRegistrar_FailedFindMIB(t *testing.T) {
 //	assert.Error(t, err)
 //	assert.Nil(t, devices)
 //}
+
+func TestSnmpDeviceRegistrar_FailedFindMIBInList(t *testing.T) {
+	defer mibs.Clear()
+
+	err := mibs.Register(&mibs.MIB{Name: "test-mib"})
+	assert.NoError(t, err)
+
+	devices, err := SnmpDeviceRegistrar(map[string]interface{}{
+		"mibs":    []interface{}{"test-mib", "other-mib"}, // other-mib not registered
+		"version": "v3",
+		"agent":   "localhost",
+		"timeout": "1s",
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, devices)
+}
+
+func TestNewSnmpDeviceRegistrar_UsesRegistry(t *testing.T) {
+	defer mibs.Clear()
+
+	// The MIB is registered with the default registry, but not the registry
+	// which the registrar uses, so it will not be found.
+	err := mibs.Register(&mibs.MIB{Name: "test-mib"})
+	assert.NoError(t, err)
+
+	registrar := NewSnmpDeviceRegistrar(mibs.NewRegistry())
+	devices, err := registrar(map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v3",
+		"agent":   "localhost",
+		"timeout": "1s",
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, devices)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/vapor-ware/synse-sdk/sdk"
 	"github.com/vapor-ware/synse-snmp-base/pkg/handlers"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
 )
 
 // Errors for base plugin setup/creation.
@@ -22,12 +23,68 @@ type PluginMetadata struct {
 	VCS         string
 }
 
+// Option is an option for configuring the SNMP base plugin.
+type Option func(*options)
+
+// options holds the configurable components of the SNMP base plugin.
+type options struct {
+	registry  *mibs.Registry
+	registrar *Registrar
+
+	// dynamicConfigOptional makes the dynamic registration configuration optional.
+	dynamicConfigOptional bool
+}
+
+// WithRegistry is an Option which sets the MIB registry which the SNMP base plugin
+// loads MIBs from. If not set, the default registry (mibs.DefaultRegistry) is used.
+func WithRegistry(registry *mibs.Registry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// WithRegistrar is an Option which sets the Registrar used to register devices for
+// the configured SNMP targets. This allows the plugin implementation to keep a
+// reference to the registrar, e.g. to get the registration status of the targets.
+// If not set, a new Registrar is created for the plugin's MIB registry.
+func WithRegistrar(registrar *Registrar) Option {
+	return func(o *options) {
+		o.registrar = registrar
+	}
+}
+
+// WithOptionalDynamicConfig is an Option which makes the dynamic registration
+// configuration optional, for plugins whose devices may all be defined in the Synse
+// device configuration. By default, the dynamic registration configuration is
+// required.
+func WithOptionalDynamicConfig() Option {
+	return func(o *options) {
+		o.dynamicConfigOptional = true
+	}
+}
+
+// newOptions applies the given Options to the SNMP base plugin defaults.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		registry: mibs.DefaultRegistry,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.registrar == nil {
+		o.registrar = NewRegistrar(o.registry)
+	}
+	return o
+}
+
 // NewSnmpBasePlugin creates a new SNMP base plugin.
 //
 // This base plugin can be used by other plugin implementations to inherit generic
 // SNMP handling. Plugin implementations need only provide plugin metadata for the
 // "subclassed" plugin and info mapping MIB devices to Synse devices.
-func NewSnmpBasePlugin(metadata *PluginMetadata) (*sdk.Plugin, error) {
+func NewSnmpBasePlugin(metadata *PluginMetadata, opts ...Option) (*sdk.Plugin, error) {
+	o := newOptions(opts...)
+
 	if metadata.Name == "" {
 		return nil, ErrNoName
 	}
@@ -42,17 +99,39 @@ func NewSnmpBasePlugin(metadata *PluginMetadata) (*sdk.Plugin, error) {
 		metadata.VCS,
 	)
 
-	plugin, err := sdk.NewPlugin(
+	pluginOpts := []sdk.PluginOption{
 		sdk.PluginConfigRequired(),
-		sdk.DynamicConfigRequired(),
 		sdk.DeviceConfigOptional(),
 		sdk.CustomDeviceIdentifier(SnmpDeviceIdentifier),
-		sdk.CustomDynamicDeviceRegistration(SnmpDeviceRegistrar),
-	)
+		sdk.CustomDeviceDataValidator(SnmpDeviceDataValidator),
+		sdk.CustomDynamicDeviceConfigRegistration(o.registrar.Prepare),
+		sdk.CustomDynamicDeviceRegistration(o.registrar.Register),
+	}
+	if !o.dynamicConfigOptional {
+		pluginOpts = append(pluginOpts, sdk.DynamicConfigRequired())
+	}
+
+	plugin, err := sdk.NewPlugin(pluginOpts...)
 	if err != nil {
 		return nil, err
 	}
 
+	// Devices defined in the device configuration carry their SNMP target
+	// configuration in their Data, which is loaded before they are read.
+	err = plugin.RegisterDeviceSetupActions(configuredDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop any background device discovery when the plugin terminates.
+	plugin.RegisterPostRunActions(&sdk.PluginAction{
+		Name: "stop snmp device discovery",
+		Action: func(_ *sdk.Plugin) error {
+			o.registrar.Stop()
+			return nil
+		},
+	})
+
 	// Since this is a generic base, no custom output types are registered
 	// to the plugin instance here. Plugins which use this as the base ar
 	// free to add their own custom outputs once they have this generic base
@@ -63,9 +142,10 @@ func NewSnmpBasePlugin(metadata *PluginMetadata) (*sdk.Plugin, error) {
 	//       &customOutput,
 	//   )
 
+	// Register the SNMP device handlers, including any custom handlers which the
+	// plugin implementation registered with the handlers package.
 	err = plugin.RegisterDeviceHandlers(
-		&handlers.ReadOnly,
-		&handlers.ReadWrite,
+		handlers.All()...,
 	)
 	if err != nil {
 		return nil, err
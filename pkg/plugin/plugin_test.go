@@ -4,7 +4,10 @@ import (
 	"os"
 	"testing"
 
+	"github.com/soniah/gosnmp"
 	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
 )
 
 func TestNewSnmpBasePlugin(t *testing.T) {
@@ -28,6 +31,59 @@ func TestNewSnmpBasePlugin(t *testing.T) {
 	assert.NotNil(t, plugin)
 }
 
+func TestNewSnmpBasePlugin_WithRegistry(t *testing.T) {
+	if err := os.Setenv("PLUGIN_CONFIG", "./testdata/config.yml"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("PLUGIN_CONFIG")
+
+	registry := mibs.NewRegistry()
+	plugin, err := NewSnmpBasePlugin(
+		&PluginMetadata{
+			Name:        "test",
+			Maintainer:  "test",
+			Description: "test",
+			VCS:         "test",
+		},
+		WithRegistry(registry),
+	)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, plugin)
+}
+
+func TestNewOptions_WithRegistry(t *testing.T) {
+	registry := newTestRegistry(t)
+	assert.Nil(t, mibs.Get("test-mib"))
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+
+	o := newOptions(WithRegistry(registry))
+	defer o.registrar.Stop()
+	assert.True(t, o.registry == registry)
+
+	// Devices are registered from the MIBs in the supplied registry, rather than
+	// from the default registry.
+	devices, err := o.registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.Equal(t, "test-mib", devices[0].Data["mib"])
+	assert.Equal(t, "1.2.3.4", devices[0].Data["oid"])
+}
+
+func TestNewOptions_DefaultRegistry(t *testing.T) {
+	o := newOptions()
+	defer o.registrar.Stop()
+	assert.True(t, o.registry == mibs.DefaultRegistry)
+}
+
 func TestNewSnmpBasePlugin_NoConfig(t *testing.T) {
 	plugin, err := NewSnmpBasePlugin(&PluginMetadata{
 		Name:        "test",
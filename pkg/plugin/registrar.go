@@ -0,0 +1,992 @@
+package exp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// uptimeProbeInterval is the interval at which the sysUpTime of periodically
+// rediscovered agents is probed in order to detect agent restarts.
+const uptimeProbeInterval = 30 * time.Second
+
+// Registrar registers Synse devices for the SNMP targets configured in the plugin's
+// dynamic registration block. It keeps track of the registration status of each
+// target and runs background discovery for targets which use deferred registration.
+type Registrar struct {
+	registry *mibs.Registry
+	workers  int
+
+	mu      sync.RWMutex
+	targets []*core.Target
+
+	queue     []*queuedTarget
+	batchOnce sync.Once
+	summary   *DiscoverySummary
+
+	cache       *discoveryCache
+	coverageDir string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewRegistrar creates a new Registrar which loads devices for the MIBs registered
+// with the given MIB registry.
+func NewRegistrar(registry *mibs.Registry, opts ...RegistrarOption) *Registrar {
+	r := &Registrar{
+		registry: registry,
+		workers:  DefaultWorkers,
+		stop:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register builds the Synse devices for a single SNMP target. It has the signature
+// of an SDK dynamic device registrar, so it can be used as one directly.
+//
+// The registrar loads all devices for the specified MIBs and caches the SNMP
+// configuration for each device. This allows each device to create a new client
+// on demand using this pre-loaded configuration.
+//
+// If the target configuration does not specify any MIBs, the MIBs are auto-detected
+// by matching the agent's sysObjectID and sysORTable against the registered MIBs.
+// Devices are loaded for every matching MIB.
+//
+// Devices whose condition is not met are not loaded. If the agent does not support
+// all of the required devices of the MIBs, discovery fails, unless the target is
+// configured to only be marked as degraded.
+//
+// If the target uses deferred registration and the agent cannot be reached, all
+// devices defined by the target's MIBs are registered and discovery is retried
+// in the background. The devices do not report readings until discovery succeeds,
+// after which only the devices the agent supports report readings.
+//
+// If the discovery cache is enabled (see WithDiscoveryCache) and holds results for
+// the target which were stored for the same agent (or the agent can not be reached),
+// its devices are registered from the cache without waiting for discovery, and
+// discovery is refreshed in the background.
+//
+// If the target's status device is enabled, a device which reports the reachability
+// and latency of the agent is registered along with the target's devices.
+//
+// If the target configuration was queued with Prepare, discovery runs for all queued
+// targets concurrently on the first call to Register (see RegisterAll), and this
+// returns the devices discovered for the given target. A queued target which fails
+// discovery is reported in the discovery summary and registers no devices, rather
+// than failing registration for every other target.
+func (r *Registrar) Register(data map[string]interface{}) ([]*sdk.Device, error) {
+	if q := r.queued(data); q != nil {
+		r.batchOnce.Do(r.registerQueued)
+		return q.devices, nil
+	}
+
+	_, devices, err := r.register(data)
+	return devices, err
+}
+
+// register builds the Synse devices for a single SNMP target, returning the target
+// state along with the devices. The target is nil if the target configuration is
+// invalid.
+//
+// If the target's status device is enabled, it is added to the target's devices
+// and the agent's status is monitored in the background (see monitor).
+func (r *Registrar) register(data map[string]interface{}) (*core.Target, []*sdk.Device, error) {
+	target, devices, err := r.registerTarget(data)
+	if err != nil || !target.Config.Status.Enabled {
+		return target, devices, err
+	}
+	status := statusDevice(target)
+	if err := identify(target, status); err != nil {
+		return target, nil, err
+	}
+	r.monitor(target)
+	return target, append(devices, status), nil
+}
+
+// registerTarget builds the Synse devices defined by the MIBs of a single SNMP
+// target (see register).
+func (r *Registrar) registerTarget(data map[string]interface{}) (*core.Target, []*sdk.Device, error) {
+	// Load the data into a configurations struct.
+	config, err := core.LoadTargetConfiguration(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If MIBs are configured for the agent, get them. Otherwise, the MIBs will be
+	// auto-detected once connected to the agent, so make sure there is something
+	// to detect against.
+	var targetMibs []*mibs.MIB
+	for _, name := range config.MIBNames() {
+		mib := r.registry.Get(name)
+		if mib == nil {
+			log.WithFields(log.Fields{
+				"mib": name,
+			}).Error("[snmp] specified MIB is not registered with the plugin")
+			return nil, nil, fmt.Errorf("configured MIB not found: %s", name)
+		}
+		targetMibs = append(targetMibs, mib)
+	}
+	detected := len(targetMibs) == 0
+	if detected {
+		if len(r.registry.GetAll()) == 0 {
+			return nil, nil, fmt.Errorf("invalid configuration: no MIB specified for agent %s and no MIBs registered to detect", config.Agent)
+		}
+		// Without the agent, there is no way to know which devices to register,
+		// so deferred registration requires the MIBs to be known up front.
+		if config.Discovery.Deferred {
+			return nil, nil, fmt.Errorf("invalid configuration: deferred registration for agent %s requires MIBs to be specified", config.Agent)
+		}
+	}
+
+	// Create an SNMP client for the configured target. The client is shared
+	// for all MIBs loaded for the target.
+	target := core.NewTarget(config, mibNames(targetMibs))
+	c, err := target.NewClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close()
+	defer applyDeadline(c, config)()
+
+	r.addTarget(target)
+
+	// The identity of the agent is used in the IDs of the target's devices. If
+	// it needs to be gotten from the agent, it is resolved once connected, or
+	// taken from the discovery cache.
+	if !config.Identity.RequiresAgent() {
+		identity, err := resolveIdentity(c, config)
+		if err != nil {
+			return target, nil, err
+		}
+		target.SetIdentity(identity)
+	}
+
+	// If the discovery results for the target are cached, and the cache can be
+	// trusted for the agent (see cached), register its devices from the cache
+	// right away and refresh discovery in the background. All of the devices
+	// defined by the MIBs are registered, in case the refreshed results differ
+	// from the cached ones.
+	if !detected && r.cache != nil {
+		supported, ok := r.cached(target, targetMibs)
+		if ok && config.Identity.RequiresAgent() {
+			target.SetIdentity(r.cache.identity(config.Agent, targetMibs))
+		}
+		if ok && target.Identity() != "" {
+			target.Restored(supported)
+			log.WithFields(log.Fields{
+				"agent":     config.Agent,
+				"mibs":      mibNames(targetMibs),
+				"supported": len(supported),
+			}).Info("[snmp] registered devices for agent from discovery cache; refreshing discovery in background")
+
+			devices, err := loadDevices(target, targetMibs, definedOids(targetMibs, supported), false)
+			if err != nil {
+				return target, nil, err
+			}
+			r.retryDiscovery(target, targetMibs, 0)
+			return target, devices, nil
+		}
+	}
+
+	var result *discovery
+	err = c.Connect()
+	if err == nil && target.Identity() == "" {
+		var identity string
+		identity, err = resolveIdentity(c, config)
+		target.SetIdentity(identity)
+	}
+	if err == nil && detected {
+		targetMibs, err = detectMibs(c, config, r.registry)
+	}
+	if err == nil {
+		result, err = discoverTarget(c, config, targetMibs)
+	}
+	if err != nil {
+		// Without the agent's identity, the IDs of the target's devices are not
+		// known, so their registration can not be deferred.
+		if !config.Discovery.Deferred || target.Identity() == "" {
+			target.DiscoveryFailed(err, time.Time{})
+			return target, nil, err
+		}
+
+		next := time.Now().Add(config.Discovery.RetryInterval)
+		target.DiscoveryFailed(err, next)
+		log.WithError(err).WithFields(log.Fields{
+			"agent":     config.Agent,
+			"mibs":      mibNames(targetMibs),
+			"nextRetry": next,
+		}).Warn("[snmp] agent unreachable; deferring device registration")
+
+		devices, err := loadDevices(target, targetMibs, definedOids(targetMibs, nil), false)
+		if err != nil {
+			return target, nil, err
+		}
+		r.retryDiscovery(target, targetMibs, config.Discovery.RetryInterval)
+		return target, devices, nil
+	}
+
+	supported := result.supported
+	if !detected {
+		r.updateCache(c, target, targetMibs, result)
+	}
+	target.SetMIBs(mibNames(targetMibs))
+	r.discovered(target, targetMibs, result)
+	log.WithFields(log.Fields{
+		"agent":     config.Agent,
+		"mibs":      mibNames(targetMibs),
+		"supported": len(supported),
+	}).Info("[snmp] discovered devices for agent")
+
+	// If the target is periodically rediscovered, all devices defined by its MIBs
+	// are registered, so devices which the agent only supports later on can start
+	// reporting readings without needing to be registered. Until then, they are
+	// placeholders (see loadDevices).
+	if config.Discovery.Interval != 0 {
+		devices, err := loadDevices(target, targetMibs, definedOids(targetMibs, supported), detected)
+		if err != nil {
+			return target, nil, err
+		}
+		r.rediscover(target, targetMibs)
+		return target, devices, nil
+	}
+	devices, err := loadDevices(target, targetMibs, supported, detected)
+	return target, devices, err
+}
+
+// Status gets the registration status of every target the registrar has
+// registered, in the order in which they were registered.
+func (r *Registrar) Status() []core.TargetStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := make([]core.TargetStatus, len(r.targets))
+	for i, t := range r.targets {
+		status[i] = t.Status()
+	}
+	return status
+}
+
+// Stop stops all background discovery and waits for it to terminate.
+func (r *Registrar) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	r.wg.Wait()
+}
+
+// addTarget adds a target to the set of targets tracked by the registrar.
+func (r *Registrar) addTarget(target *core.Target) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.targets = append(r.targets, target)
+}
+
+// retryDiscovery retries device discovery for a target in the background until
+// it succeeds or the registrar is stopped. The first attempt is made after the
+// given delay. The delay between subsequent attempts starts at the target's
+// configured retry interval and doubles after each failed attempt, up to the
+// configured maximum.
+func (r *Registrar) retryDiscovery(target *core.Target, targetMibs []*mibs.MIB, delay time.Duration) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		cfg := target.Config.Discovery
+		interval := delay
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(interval):
+			}
+
+			result, err := r.discover(target, targetMibs)
+			if err == nil {
+				r.reidentify(target, targetMibs)
+				flagUnregistered(target, targetMibs, result.supported)
+				r.discovered(target, targetMibs, result)
+				log.WithFields(log.Fields{
+					"agent":     target.Config.Agent,
+					"attempts":  target.Status().Attempts,
+					"supported": len(result.supported),
+				}).Info("[snmp] discovered devices for agent in background")
+
+				if cfg.Interval != 0 {
+					r.rediscover(target, targetMibs)
+				}
+				return
+			}
+
+			if interval == 0 {
+				interval = cfg.RetryInterval
+			} else {
+				interval *= 2
+			}
+			if interval > cfg.MaxRetryInterval {
+				interval = cfg.MaxRetryInterval
+			}
+			next := time.Now().Add(interval)
+			target.DiscoveryFailed(err, next)
+			log.WithError(err).WithFields(log.Fields{
+				"agent":     target.Config.Agent,
+				"attempts":  target.Status().Attempts,
+				"nextRetry": next,
+			}).Warn("[snmp] agent unreachable; will retry discovery")
+		}
+	}()
+}
+
+// rediscover periodically rediscovers the devices supported by a target's agent in
+// the background, until the registrar is stopped. Rediscovery happens at the
+// target's configured discovery interval, as well as whenever the agent is found
+// to have restarted.
+//
+// Devices for OIDs which the agent newly supports start reporting readings, and
+// devices for OIDs which the agent no longer supports are retired: reading them fails
+// with core.ErrRetired, and they are listed in the target's status. Since device IDs
+// are derived from the agent, MIB and OID, a device which is retired and later added
+// again keeps its ID. The MIBs of targets with auto-detected MIBs are detected again
+// before each rediscovery (see redetect).
+func (r *Registrar) rediscover(target *core.Target, targetMibs []*mibs.MIB) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		interval := target.Config.Discovery.Interval
+		rediscovery := time.NewTicker(interval)
+		defer rediscovery.Stop()
+
+		// Restarts are detected from any response which holds the agent's
+		// sysUpTime or snmpEngineBoots. The sysUpTime is probed as well, which
+		// is much cheaper than a full rediscovery, so it can be done more
+		// frequently.
+		probeInterval := uptimeProbeInterval
+		if interval < probeInterval {
+			probeInterval = interval
+		}
+		probe := time.NewTicker(probeInterval)
+		defer probe.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-probe.C:
+				probeUptime(target)
+				continue
+			case <-target.Restarts():
+				log.WithField("agent", target.Config.Agent).Info("[snmp] agent restart detected; rediscovering devices")
+			case <-rediscovery.C:
+			}
+
+			// The agent may have been replaced by one which implements
+			// other MIBs, so the MIBs of auto-detected targets are detected
+			// again.
+			if len(target.Config.MIBNames()) == 0 {
+				targetMibs = r.redetect(target, targetMibs)
+			}
+
+			result, err := r.discover(target, targetMibs)
+			if err != nil {
+				target.DiscoveryFailed(err, time.Now().Add(interval))
+				log.WithError(err).WithFields(log.Fields{
+					"agent": target.Config.Agent,
+				}).Warn("[snmp] failed to rediscover devices; keeping previously discovered devices")
+				continue
+			}
+
+			// Instances of device OID patterns which first appear now have no
+			// registered device, so they are reported separately from the
+			// devices which were added.
+			unregistered := flagUnregistered(target, targetMibs, result.supported)
+			var added, retired []string
+			for oid := range definedOids(targetMibs, result.supported) {
+				_, isSupported := result.supported[oid]
+				wasSupported := target.IsSupported(oid)
+				if _, ok := unregistered[oid]; ok {
+					continue
+				}
+				if isSupported && !wasSupported {
+					added = append(added, oid)
+				} else if wasSupported && !isSupported {
+					retired = append(retired, oid)
+				}
+			}
+			r.discovered(target, targetMibs, result)
+
+			if len(added) != 0 || len(retired) != 0 {
+				sort.Strings(added)
+				sort.Strings(retired)
+				log.WithFields(log.Fields{
+					"agent":   target.Config.Agent,
+					"added":   added,
+					"retired": retired,
+				}).Info("[snmp] rediscovery changed supported devices for agent")
+			} else {
+				log.WithField("agent", target.Config.Agent).Debug("[snmp] rediscovery found no device changes for agent")
+			}
+		}
+	}()
+}
+
+// redetect detects the MIBs which the agent of a target with auto-detected MIBs
+// implements, returning them. If detection fails, the given MIBs are kept.
+//
+// Only the devices of the MIBs detected at registration are registered, so if the
+// detected MIBs change, the target is flagged as requiring a restart (see
+// core.Target.RequireRestart). Devices of MIBs which are no longer detected are
+// retired by the following discovery.
+func (r *Registrar) redetect(target *core.Target, targetMibs []*mibs.MIB) []*mibs.MIB {
+	c, err := target.NewClient()
+	if err != nil {
+		return targetMibs
+	}
+	defer c.Close()
+	defer applyDeadline(c, target.Config)()
+
+	if err := c.Connect(); err != nil {
+		return targetMibs
+	}
+	detected, err := detectMibs(c, target.Config, r.registry)
+	if err != nil {
+		log.WithError(err).WithField("agent", target.Config.Agent).Warn("[snmp] failed to detect MIBs for agent; keeping previously detected MIBs")
+		return targetMibs
+	}
+
+	previous, current := mibNames(targetMibs), mibNames(detected)
+	if strings.Join(previous, ",") != strings.Join(current, ",") {
+		log.WithFields(log.Fields{
+			"agent":    target.Config.Agent,
+			"previous": previous,
+			"current":  current,
+		}).Warn("[snmp] detected MIBs changed for agent; restart the plugin to register their devices")
+		target.SetMIBs(current)
+		target.RequireRestart(fmt.Sprintf("detected MIBs changed from %v to %v", previous, current))
+	}
+	return detected
+}
+
+// reidentify resolves the identity of a target's agent again once it has been
+// discovered in the background. The target's devices may have been registered with an
+// identity from the discovery cache, or from before the agent was replaced; since the
+// identity is part of the registered device IDs, a changed identity is only logged and
+// the plugin flagged to be restarted, and the agent's cache entries are invalidated so
+// they are not used with the stale identity again. Failures are only logged.
+func (r *Registrar) reidentify(target *core.Target, targetMibs []*mibs.MIB) {
+	config := target.Config
+	if !config.Identity.RequiresAgent() {
+		return
+	}
+	c, err := target.NewClient()
+	if err != nil {
+		return
+	}
+	defer c.Close()
+	defer applyDeadline(c, config)()
+
+	if err := c.Connect(); err != nil {
+		return
+	}
+	identity, err := resolveIdentity(c, config)
+	if err != nil {
+		log.WithError(err).WithField("agent", config.Agent).Warn("[snmp] failed to resolve agent identity after discovery")
+		return
+	}
+
+	previous := target.Identity()
+	if identity == previous {
+		return
+	}
+	log.WithFields(log.Fields{
+		"agent":    config.Agent,
+		"previous": previous,
+		"current":  identity,
+	}).Warn("[snmp] agent identity changed; restart the plugin to register its devices with new IDs")
+	if r.cache != nil {
+		r.cache.invalidate(config.Agent, targetMibs)
+	}
+	target.RequireRestart(fmt.Sprintf("agent identity changed from %q to %q", previous, identity))
+}
+
+// probeUptime gets the sysUpTime of the target's agent in order to detect agent
+// restarts. As with any request, the outcome of the probe and the agent's uptime
+// are recorded in the agent's health by the client, which notifies the target of
+// restarts. Failures are only logged, as the probe is best-effort.
+func probeUptime(target *core.Target) {
+	c, err := target.NewClient()
+	if err != nil {
+		return
+	}
+	defer c.Close()
+
+	if err := c.Connect(); err != nil {
+		return
+	}
+	if _, err := c.GetUptime(); err != nil {
+		log.WithError(err).WithField("agent", target.Config.Agent).Debug("[snmp] failed to probe agent uptime")
+	}
+}
+
+// discover connects to the target's agent with a new client and gets the OIDs
+// the agent supports for the given MIBs, along with their values. If the discovery
+// cache is enabled, the results are cached.
+func (r *Registrar) discover(target *core.Target, targetMibs []*mibs.MIB) (*discovery, error) {
+	c, err := target.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	defer applyDeadline(c, target.Config)()
+
+	if err := c.Connect(); err != nil {
+		return nil, err
+	}
+	result, err := discoverTarget(c, target.Config, targetMibs)
+	if err != nil {
+		return nil, err
+	}
+	result.keepUnwalked(target)
+
+	// Targets with auto-detected MIBs do not use the cache.
+	if len(target.Config.MIBNames()) != 0 {
+		r.updateCache(c, target, targetMibs, result)
+	}
+	return result, nil
+}
+
+// discovered records the result of a successful discovery for a target: the OIDs
+// the agent supports, any missing required devices, the decoders for devices with
+// mismatched types, and the discovered values, which seed the first readings. The
+// discovery coverage is reported as well.
+func (r *Registrar) discovered(target *core.Target, targetMibs []*mibs.MIB, result *discovery) {
+	target.Discovered(result.supported)
+	if len(result.missing) != 0 {
+		target.Degraded(result.missing)
+	}
+	target.SetDecoders(result.decoders())
+	seed(target, targetMibs, result.values)
+	r.reportCoverage(target, targetMibs, result)
+}
+
+// cached gets the cached supported OIDs of a target, if there are valid cache
+// entries for all of its MIBs and they were stored for the same agent. Before the
+// cache is trusted, the agent's sysObjectID is gotten: if it differs from the one
+// the entries were stored for, the agent has been replaced, so the entries are
+// invalidated and not used. The entries are only used without confirming the
+// sysObjectID if the agent can not be reached.
+func (r *Registrar) cached(target *core.Target, targetMibs []*mibs.MIB) (map[string]struct{}, bool) {
+	config := target.Config
+	supported, ok := r.cache.loadAll(config.Agent, targetMibs)
+	if !ok {
+		return nil, false
+	}
+
+	c, err := target.NewClient()
+	if err != nil {
+		return nil, false
+	}
+	defer c.Close()
+	defer applyDeadline(c, config)()
+
+	var sysObjectID string
+	if err = c.Connect(); err == nil {
+		sysObjectID, err = c.GetSysObjectID()
+	}
+	if err != nil {
+		log.WithError(err).WithField("agent", config.Agent).Debug("[snmp] agent unreachable; using cached discovery results")
+		return supported, true
+	}
+
+	if cached := r.cache.sysObjectID(config.Agent, targetMibs); cached != sysObjectID {
+		log.WithFields(log.Fields{
+			"agent":    config.Agent,
+			"previous": cached,
+			"current":  sysObjectID,
+		}).Info("[snmp] agent identity changed; invalidating discovery cache")
+		r.cache.invalidate(config.Agent, targetMibs)
+		return nil, false
+	}
+	return supported, true
+}
+
+// updateCache stores the discovery results for a target in the discovery cache,
+// if it is enabled. If the agent's sysObjectID differs from the one the existing
+// cache entries were stored for, the agent has been replaced, so the entries are
+// invalidated before the new results are stored. Results of a discovery whose walks
+// ended early are not stored, so the cache keeps the previous results.
+func (r *Registrar) updateCache(c *core.Client, target *core.Target, targetMibs []*mibs.MIB, result *discovery) {
+	if r.cache == nil {
+		return
+	}
+	config := target.Config
+	if result.partial != nil {
+		log.WithError(result.partial).WithField("agent", config.Agent).Warn("[snmp] discovery incomplete; not caching discovery results")
+		return
+	}
+	supported := result.supported
+
+	sysObjectID, err := c.GetSysObjectID()
+	if err != nil {
+		log.WithError(err).WithField("agent", config.Agent).Warn("[snmp] failed to get agent sysObjectID; not caching discovery results")
+		return
+	}
+	if cached := r.cache.sysObjectID(config.Agent, targetMibs); cached != "" && cached != sysObjectID {
+		log.WithFields(log.Fields{
+			"agent":    config.Agent,
+			"previous": cached,
+			"current":  sysObjectID,
+		}).Info("[snmp] agent identity changed; invalidating discovery cache")
+		r.cache.invalidate(config.Agent, targetMibs)
+	}
+	r.cache.store(config.Agent, sysObjectID, target.Identity(), targetMibs, supported)
+}
+
+// applyDeadline bounds the requests made with the client by the target's discovery
+// timeout, if one is configured. It must be called before the client connects. The
+// returned function releases the deadline's resources.
+func applyDeadline(c *core.Client, config *core.SnmpTargetConfiguration) context.CancelFunc {
+	if config.Discovery.Timeout == 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.Discovery.Timeout)
+	c.Context = ctx
+	return cancel
+}
+
+// discoverSupported discovers which devices of each of the given MIBs the agent
+// supports, using the discovery strategy of each MIB (or of the target, if set),
+// and merges the results into a single set of supported OIDs and their values.
+//
+// For MIBs which are walked, root OIDs which are shared between MIBs are only
+// walked once. For MIBs which are probed, the device OIDs are fetched directly
+// and the MIB's table columns are probed. The OIDs of device conditions which
+// were not discovered otherwise are fetched directly as well.
+//
+// If any walk ends early, the discovered values are returned along with a
+// core.PartialDiscoveryError.
+func discoverSupported(c *core.Client, config *core.SnmpTargetConfiguration, targetMibs []*mibs.MIB) (core.DiscoveredValues, error) {
+	var roots, probes, columns []string
+	seen := map[string]struct{}{}
+	add := func(list []string, oid string) []string {
+		if _, exists := seen[oid]; exists {
+			return list
+		}
+		seen[oid] = struct{}{}
+		return append(list, oid)
+	}
+
+	for _, mib := range targetMibs {
+		if discoveryStrategy(config, mib) == core.DiscoveryProbe {
+			// Devices whose OID is a pattern cannot be fetched directly, so
+			// their prefixes are probed like table columns instead.
+			for _, d := range mib.ResolvedDevices() {
+				if d.IsPattern() {
+					for _, prefix := range d.Prefixes() {
+						columns = add(columns, prefix)
+					}
+				} else {
+					for _, oid := range d.OIDs() {
+						probes = add(probes, oid)
+					}
+				}
+			}
+			for _, oid := range mib.InputOids() {
+				probes = add(probes, oid)
+			}
+			for _, column := range mib.Columns {
+				columns = add(columns, column)
+			}
+			continue
+		}
+		for _, root := range mib.Roots() {
+			roots = add(roots, root)
+		}
+	}
+
+	values := core.DiscoveredValues{}
+	var partial *core.PartialDiscoveryError
+	if len(roots) != 0 {
+		walked, err := c.GetSupportedValues(roots...)
+		if perr, ok := err.(*core.PartialDiscoveryError); ok {
+			partial = perr
+		} else if err != nil {
+			return nil, err
+		}
+		merge(values, walked)
+	}
+	if len(probes) != 0 {
+		probed, err := c.ProbeValues(probes...)
+		if err != nil {
+			return nil, err
+		}
+		merge(values, probed)
+	}
+	if len(columns) != 0 {
+		probed, err := c.ProbeColumnValues(columns...)
+		if err != nil {
+			return nil, err
+		}
+		merge(values, probed)
+	}
+
+	var conditions []string
+	for _, oid := range conditionOids(targetMibs) {
+		if _, ok := values[oid]; !ok {
+			conditions = append(conditions, oid)
+		}
+	}
+	if len(conditions) != 0 {
+		probed, err := c.ProbeValues(conditions...)
+		if err != nil {
+			return nil, err
+		}
+		merge(values, probed)
+	}
+	if partial != nil {
+		return values, partial
+	}
+	return values, nil
+}
+
+// discoveryStrategy gets the strategy used to discover the devices of a MIB for a
+// target. The target's strategy takes precedence over the MIB's.
+func discoveryStrategy(config *core.SnmpTargetConfiguration, mib *mibs.MIB) string {
+	if config.Discovery.Strategy != "" {
+		return config.Discovery.Strategy
+	}
+	if mib.DiscoveryStrategy != "" {
+		return mib.DiscoveryStrategy
+	}
+	return core.DiscoveryWalk
+}
+
+// merge adds all discovered values from one set of discovered values to another.
+func merge(values, discovered core.DiscoveredValues) {
+	for oid, v := range discovered {
+		values[oid] = v
+	}
+}
+
+// seed seeds the target with the discovered values of the devices defined by the
+// given MIBs, so the first reading of each device does not need to get its OID
+// from the agent again.
+func seed(target *core.Target, targetMibs []*mibs.MIB, values core.DiscoveredValues) {
+	defined := definedOids(targetMibs, values.OIDs())
+	seeds := core.DiscoveredValues{}
+	for oid, v := range values {
+		if _, ok := defined[oid]; ok {
+			seeds[oid] = v
+		}
+	}
+	target.Seed(seeds)
+}
+
+// loadDevices loads the Synse devices for each of the given MIBs which are in the
+// set of supported OIDs. Each device is given a reference to the target's runtime
+// state.
+//
+// If more than one MIB defines a device with the same OID (or a computed device with
+// the same key), the device is only loaded for the first MIB which defines it; the
+// duplicate is reported and skipped.
+//
+// Devices which the target's agent is not yet known to support (e.g. when registration
+// is deferred) are placeholders: they have "placeholder" set in their context, and
+// report no readings until discovery finds the agent supports them. The placeholders
+// of a target are listed in its status (see core.TargetStatus).
+func loadDevices(target *core.Target, targetMibs []*mibs.MIB, supported map[string]struct{}, detected bool) ([]*sdk.Device, error) {
+	config := target.Config
+	owners := map[string]string{}
+	var registered []string
+
+	var devices []*sdk.Device
+	for _, mib := range targetMibs {
+		d, err := mib.LoadDevices(config, supported)
+		if err != nil {
+			log.WithError(err).Error("[snmp] failed to load devices from MIB")
+			return nil, err
+		}
+
+		for _, device := range d {
+			id, ok := device.Data["oid"].(string)
+			if !ok {
+				id = fmt.Sprintf("computed:%v", device.Data["key"])
+			}
+			if owner, exists := owners[id]; exists {
+				log.WithFields(log.Fields{
+					"oid":   id,
+					"agent": config.Agent,
+					"mib":   mib.Name,
+					"owner": owner,
+				}).Warn("[snmp] duplicate device OID across MIBs; skipping duplicate")
+				continue
+			}
+			owners[id] = mib.Name
+
+			// Expose the detected MIB in the device context so it is clear which
+			// MIB a reading originated from.
+			if detected {
+				device.Context["mib"] = mib.Name
+			}
+			if oid, ok := device.Data["oid"].(string); ok {
+				registered = append(registered, oid)
+			}
+			if placeholder(target, device) {
+				device.Context["placeholder"] = "true"
+			}
+			device.Data["target"] = target
+			if err := identify(target, device); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"agent": config.Agent,
+					"mib":   mib.Name,
+				}).Error("[snmp] failed to generate device ID")
+				return nil, err
+			}
+			devices = append(devices, device)
+		}
+	}
+	target.SetRegistered(registered)
+	return devices, nil
+}
+
+// placeholder checks whether a device is registered before the target's agent is
+// known to support it: its OID, or any of a computed device's inputs, is not yet
+// supported.
+func placeholder(target *core.Target, device *sdk.Device) bool {
+	if oid, ok := device.Data["oid"].(string); ok {
+		return !target.IsSupported(oid)
+	}
+	inputs, _ := device.Data["inputs"].([]string)
+	for _, oid := range inputs {
+		if !target.IsSupported(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagUnregistered finds the instances of device OID patterns among the given
+// supported OIDs which have no registered device (see unregisteredInstances). Since
+// devices can only be registered when the plugin starts, the target is flagged as
+// requiring a restart if there are any. The OIDs of the instances are returned.
+func flagUnregistered(target *core.Target, targetMibs []*mibs.MIB, supported map[string]struct{}) map[string]struct{} {
+	unregistered := unregisteredInstances(target, targetMibs, supported)
+	if len(unregistered) == 0 {
+		return unregistered
+	}
+
+	instances := make([]string, 0, len(unregistered))
+	for oid := range unregistered {
+		instances = append(instances, oid)
+	}
+	sort.Strings(instances)
+	log.WithFields(log.Fields{
+		"agent":     target.Config.Agent,
+		"instances": instances,
+	}).Warn("[snmp] discovered device instances which are not registered for agent; restart the plugin to register them")
+	target.RequireRestart(fmt.Sprintf("discovered unregistered device instances %v", instances))
+	return unregistered
+}
+
+// unregisteredInstances gets the OIDs of the given supported OIDs which match a
+// device OID pattern of the given MIBs, but have no device registered for them,
+// along with the OIDs of the additional readings of their devices. Devices are
+// only registered for the pattern instances discovered at registration, so these
+// instances require the plugin to be restarted.
+func unregisteredInstances(target *core.Target, targetMibs []*mibs.MIB, supported map[string]struct{}) map[string]struct{} {
+	instances := map[string]struct{}{}
+	for _, mib := range targetMibs {
+		for _, d := range mib.ResolvedDevices() {
+			if !d.IsPattern() {
+				continue
+			}
+			for oid := range supported {
+				if _, ok := d.Match(oid); !ok || target.IsRegistered(oid) {
+					continue
+				}
+				instances[oid] = struct{}{}
+			}
+		}
+	}
+	if len(instances) == 0 {
+		return instances
+	}
+	for _, mib := range targetMibs {
+		for _, d := range mib.ExpandedDevices(supported) {
+			if _, ok := instances[d.OID]; !ok {
+				continue
+			}
+			for _, oid := range d.OIDs() {
+				instances[oid] = struct{}{}
+			}
+		}
+	}
+	return instances
+}
+
+// definedOids gets the set of all device OIDs defined by the given MIBs, including the
+// OIDs of additional device readings and the inputs of computed devices. Devices whose
+// OID is a pattern contribute the discovered OIDs which match the pattern.
+func definedOids(targetMibs []*mibs.MIB, discovered map[string]struct{}) map[string]struct{} {
+	oids := map[string]struct{}{}
+	for _, mib := range targetMibs {
+		for _, d := range mib.ExpandedDevices(discovered) {
+			for _, oid := range d.OIDs() {
+				oids[oid] = struct{}{}
+			}
+		}
+		for _, oid := range mib.InputOids() {
+			oids[oid] = struct{}{}
+		}
+	}
+	return oids
+}
+
+// mibNames gets the names of the given MIBs.
+func mibNames(targetMibs []*mibs.MIB) []string {
+	var names []string
+	for _, mib := range targetMibs {
+		names = append(names, mib.Name)
+	}
+	return names
+}
+
+// detectMibs gets the identity of the agent and finds all registered MIBs which
+// the agent implements.
+func detectMibs(c *core.Client, config *core.SnmpTargetConfiguration, registry *mibs.Registry) ([]*mibs.MIB, error) {
+	identity, err := c.GetAgentIdentity()
+	if err != nil {
+		log.WithError(err).WithField("agent", config.Agent).Error("[snmp] failed to get agent identity for MIB detection")
+		return nil, err
+	}
+
+	detected := registry.Detect(identity)
+	if len(detected) == 0 {
+		log.WithFields(log.Fields{
+			"agent":        config.Agent,
+			"sysObjectID":  identity.SysObjectID,
+			"capabilities": identity.Capabilities,
+		}).Error("[snmp] no registered MIB matches agent")
+		return nil, fmt.Errorf("unable to detect MIB for agent %s: no registered MIB matches", config.Agent)
+	}
+
+	log.WithFields(log.Fields{
+		"agent":       config.Agent,
+		"sysObjectID": identity.SysObjectID,
+		"mibs":        mibNames(detected),
+	}).Info("[snmp] detected MIBs for agent")
+	return detected, nil
+}
@@ -0,0 +1,613 @@
+package exp
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/vapor-ware/synse-snmp-base/internal/snmptest"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/handlers"
+	"github.com/vapor-ware/synse-snmp-base/pkg/mibs"
+)
+
+// newTestRegistry creates a MIB registry with a single test MIB registered.
+func newTestRegistry(t *testing.T) *mibs.Registry {
+	registry := mibs.NewRegistry()
+	err := registry.Register(&mibs.MIB{
+		Name:    "test-mib",
+		RootOid: "1.2.3",
+		Devices: []*mibs.SnmpDevice{
+			{
+				OID:     "1.2.3.4",
+				Info:    "test device 1",
+				Handler: "read-only",
+				Type:    "temperature",
+				Output:  "temperature",
+			},
+			{
+				OID:     "1.2.3.5",
+				Info:    "test device 2",
+				Handler: "read-only",
+				Type:    "temperature",
+				Output:  "temperature",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func TestRegistrar_Register_Unreachable(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	// Nothing listens on the agent port, so discovery fails.
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "127.0.0.1:1",
+		"timeout": "100ms",
+	})
+	assert.Error(t, err)
+	assert.Nil(t, devices)
+
+	status := registrar.Status()
+	assert.Len(t, status, 1)
+	assert.Equal(t, core.TargetFailed, status[0].State)
+	assert.Equal(t, 1, status[0].Attempts)
+	assert.NotEmpty(t, status[0].LastError)
+}
+
+func TestRegistrar_Register_Deferred(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	// Nothing listens on the agent port, so discovery fails and is deferred.
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "127.0.0.1:1",
+		"timeout": "100ms",
+		"discovery": map[string]interface{}{
+			"deferred":      true,
+			"retryInterval": "1h",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	status := registrar.Status()
+	assert.Len(t, status, 1)
+	assert.Equal(t, "127.0.0.1:1", status[0].Agent)
+	assert.Equal(t, []string{"test-mib"}, status[0].MIBs)
+	assert.Equal(t, core.TargetPending, status[0].State)
+	assert.Equal(t, 1, status[0].Attempts)
+	assert.NotEmpty(t, status[0].LastError)
+	assert.False(t, status[0].NextAttempt.IsZero())
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.5"}, status[0].Placeholders)
+
+	// The devices reference the target, which does not yet support any OIDs, so
+	// they are registered as placeholders.
+	for _, device := range devices {
+		target, ok := device.Data["target"].(*core.Target)
+		assert.True(t, ok)
+		assert.False(t, target.IsSupported(device.Data["oid"].(string)))
+		assert.Equal(t, "true", device.Context["placeholder"])
+	}
+}
+
+func TestRegistrar_Register_DeferredRequiresMib(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"version": "v2",
+		"agent":   "127.0.0.1:1",
+		"timeout": "100ms",
+		"discovery": map[string]interface{}{
+			"deferred": true,
+		},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, devices)
+	assert.Empty(t, registrar.Status())
+}
+
+func TestRegistrar_Stop(t *testing.T) {
+	registrar := NewRegistrar(mibs.NewRegistry())
+
+	// Stopping more than once is safe.
+	registrar.Stop()
+	registrar.Stop()
+}
+
+func TestRegistrar_Register_DiscoveryTimeout(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	// The agent never responds, so the discovery deadline is hit well before
+	// the request timeout.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   conn.LocalAddr().String(),
+		"timeout": "10s",
+		"discovery": map[string]interface{}{
+			"timeout": "100ms",
+		},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, devices)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRegistrar_Register_SeedsReadings(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.NotContains(t, devices[0].Context, "placeholder")
+	assert.Empty(t, registrar.Status()[0].Placeholders)
+
+	// Only the values of devices defined by the MIB are seeded.
+	target := devices[0].Data["target"].(*core.Target)
+	value, ok := target.TakeSeed("1.2.3.4")
+	assert.True(t, ok)
+	assert.Equal(t, 20, value.Value)
+
+	_, ok = target.TakeSeed(core.NormalizeOid(core.SysObjectIDOid))
+	assert.False(t, ok)
+}
+
+func TestRegistrar_Register_StatusDevice(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+		"status": map[string]interface{}{
+			"enabled":       true,
+			"probeInterval": "50ms",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+
+	device := devices[1]
+	assert.Equal(t, handlers.AgentStatus.Name, device.Handler)
+	assert.Equal(t, agent.Addr()+"-agent:status", SnmpDeviceIdentifier(device.Data))
+
+	// Discovery made requests to the agent, so its status is already known.
+	readings, err := handlers.AgentStatus.Read(device)
+	assert.NoError(t, err)
+	assert.Len(t, readings, 4)
+	assert.Equal(t, core.AgentReachable, readings[0].Value)
+	assert.Equal(t, core.AgentReachable, registrar.Status()[0].Health.State)
+
+	// Without other traffic, the agent is probed in the background.
+	requests := agent.Requests(gosnmp.GetRequest)
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, agent.Requests(gosnmp.GetRequest) > requests)
+}
+
+func TestRegistrar_Register_StatusDevice_Deferred(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "127.0.0.1:2",
+		"timeout": "100ms",
+		"discovery": map[string]interface{}{
+			"deferred":      true,
+			"retryInterval": "1h",
+		},
+		"status": map[string]interface{}{
+			"enabled":       true,
+			"probeInterval": "1h",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 3)
+
+	readings, err := handlers.AgentStatus.Read(devices[2])
+	assert.NoError(t, err)
+	assert.Len(t, readings, 2)
+	assert.Equal(t, core.AgentUnreachable, readings[0].Value)
+}
+
+func TestRegistrar_Register_Identity(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: core.SnmpEngineIDOid, Type: gosnmp.OctetString, Value: []byte{0x80, 0x00, 0x01}})
+
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+		"identity": map[string]interface{}{
+			"mode": "engine-id",
+			"aliases": map[string]interface{}{
+				agent.Addr() + "-test-mib:1.2.3.4": "800001-test-mib:1.2.3.4",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.Equal(t, "800001-test-mib:1.2.3.4", SnmpDeviceIdentifier(devices[0].Data))
+	assert.Equal(t, agent.Addr()+"-test-mib:1.2.3.4", devices[0].Alias)
+	assert.Equal(t, "800001", registrar.Status()[0].Identity)
+}
+
+func TestRegistrar_Register_Identity_Deferred(t *testing.T) {
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	// The agent's identity can not be resolved while it is unreachable, so the
+	// registration of its devices can not be deferred.
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":     "test-mib",
+		"version": "v2",
+		"agent":   "127.0.0.1:2",
+		"timeout": "100ms",
+		"discovery": map[string]interface{}{
+			"deferred":      true,
+			"retryInterval": "1h",
+		},
+		"identity": map[string]interface{}{
+			"mode": "engine-id",
+		},
+	})
+	assert.Error(t, err)
+	assert.Empty(t, devices)
+}
+
+func TestRegistrar_Register_RestartRediscovers(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: core.SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(500000)})
+
+	registrar := NewRegistrar(newTestRegistry(t))
+	defer registrar.Stop()
+
+	config := map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+		"discovery": map[string]interface{}{
+			"interval": "1h",
+		},
+	}
+	_, err := registrar.Register(config)
+	assert.NoError(t, err)
+
+	// Any request by the target's clients which gets the agent's sysUpTime
+	// detects the restart, which triggers a rediscovery.
+	c, err := registrar.targets[0].NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	assert.NoError(t, c.Connect())
+
+	_, err = c.GetUptime()
+	assert.NoError(t, err)
+	walks := agent.Requests(gosnmp.GetBulkRequest)
+
+	agent.Set(gosnmp.SnmpPDU{Name: core.SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(100)})
+	_, err = c.GetUptime()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, registrar.Status()[0].Health.Restarts)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for agent.Requests(gosnmp.GetBulkRequest) == walks && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, agent.Requests(gosnmp.GetBulkRequest) > walks)
+}
+
+func TestRegistrar_Register_SeparateHealth(t *testing.T) {
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: core.SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(500000)})
+
+	config := map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+	}
+	a := NewRegistrar(newTestRegistry(t))
+	defer a.Stop()
+	_, err := a.Register(config)
+	assert.NoError(t, err)
+
+	b := NewRegistrar(newTestRegistry(t))
+	defer b.Stop()
+	_, err = b.Register(config)
+	assert.NoError(t, err)
+
+	// A restart observed by one registrar's target does not affect the other.
+	probeUptime(a.targets[0])
+	agent.Set(gosnmp.SnmpPDU{Name: core.SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(100)})
+	probeUptime(a.targets[0])
+	assert.Equal(t, 1, a.Status()[0].Health.Restarts)
+	assert.Equal(t, 0, b.Status()[0].Health.Restarts)
+}
+
+func TestRegistrar_Register_RediscoveryDetectsMibs(t *testing.T) {
+	registry := mibs.NewRegistry()
+	err := registry.Register(
+		&mibs.MIB{
+			Name:           "vendor-a-mib",
+			RootOid:        "1.2.3",
+			EnterpriseOids: []string{"1.3.6.1.4.1.9999"},
+			Devices: []*mibs.SnmpDevice{
+				{OID: "1.2.3.4", Info: "device a", Handler: "read-only", Type: "temperature", Output: "temperature"},
+			},
+		},
+		&mibs.MIB{
+			Name:           "vendor-b-mib",
+			RootOid:        "1.2.4",
+			EnterpriseOids: []string{"1.3.6.1.4.1.8888"},
+			Devices: []*mibs.SnmpDevice{
+				{OID: "1.2.4.1", Info: "device b", Handler: "read-only", Type: "temperature", Output: "temperature"},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: core.SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(500000)})
+
+	registrar := NewRegistrar(registry)
+	defer registrar.Stop()
+
+	config := map[string]interface{}{
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+		"discovery": map[string]interface{}{
+			"interval": "1h",
+		},
+	}
+	devices, err := registrar.Register(config)
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.Equal(t, []string{"vendor-a-mib"}, registrar.Status()[0].MIBs)
+
+	// The agent is replaced by one which implements another MIB, and the restart
+	// triggers a rediscovery, which detects the MIBs again.
+	agent.Remove("1.2.3.4")
+	agent.Set(
+		gosnmp.SnmpPDU{Name: core.SysObjectIDOid, Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.4.1.8888"},
+		gosnmp.SnmpPDU{Name: "1.2.4.1", Type: gosnmp.Integer, Value: 20},
+		gosnmp.SnmpPDU{Name: core.SysUpTimeOid, Type: gosnmp.TimeTicks, Value: uint32(100)},
+	)
+	probeUptime(registrar.targets[0])
+
+	deadline := time.Now().Add(2 * time.Second)
+	for registrar.Status()[0].RestartRequired == "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := registrar.Status()[0]
+	assert.Contains(t, status.RestartRequired, "detected MIBs changed")
+	assert.Equal(t, []string{"vendor-b-mib"}, status.MIBs)
+
+	// The device of the MIB which is no longer detected is gone.
+	for len(registrar.Status()[0].Retired) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"1.2.3.4"}, registrar.Status()[0].Retired)
+	_, err = handlers.ReadOnly.Read(devices[0])
+	assert.True(t, errors.Is(err, core.ErrRetired))
+}
+
+func TestRegistrar_Register_RediscoveryNewPatternInstance(t *testing.T) {
+	registry := mibs.NewRegistry()
+	err := registry.Register(&mibs.MIB{
+		Name:    "test-mib",
+		RootOid: "1.2.3",
+		Devices: []*mibs.SnmpDevice{
+			{OID: "1.2.3.7.*", Info: "outlet", Handler: "read-only", Type: "temperature", Output: "temperature"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+	defer agent.Close()
+	agent.Set(gosnmp.SnmpPDU{Name: "1.2.3.7.1", Type: gosnmp.Integer, Value: 20})
+
+	registrar := NewRegistrar(registry)
+	defer registrar.Stop()
+
+	devices, err := registrar.Register(map[string]interface{}{
+		"mib":       "test-mib",
+		"version":   "v2",
+		"agent":     agent.Addr(),
+		"community": "public",
+		"timeout":   "100ms",
+		"discovery": map[string]interface{}{
+			"interval": "1h",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+	assert.Equal(t, "", registrar.Status()[0].RestartRequired)
+
+	// A new outlet is populated; its device can only be registered on restart.
+	agent.Set(gosnmp.SnmpPDU{Name: "1.2.3.7.2", Type: gosnmp.Integer, Value: 21})
+	registrar.targets[0].NotifyRestart()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for registrar.Status()[0].RestartRequired == "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := registrar.Status()[0]
+	assert.Equal(t, "discovered unregistered device instances [1.2.3.7.2]", status.RestartRequired)
+	assert.Empty(t, status.Placeholders)
+	assert.Empty(t, status.Retired)
+}
+
+func TestRegistrar_Register_Computed(t *testing.T) {
+	for _, strategy := range []string{core.DiscoveryWalk, core.DiscoveryProbe} {
+		t.Run(strategy, func(t *testing.T) {
+			registry := mibs.NewRegistry()
+			if err := registry.Register(&mibs.MIB{
+				Name:              "test-mib",
+				DiscoveryStrategy: strategy,
+				Computed: []*mibs.ComputedDevice{
+					{Key: "power", Info: "power", Type: "power", Output: "watt", Inputs: []string{"1.2.3.4", "1.2.3.6"}, Expression: "$1 * $2"},
+					{Key: "missing", Info: "missing", Type: "power", Output: "watt", Inputs: []string{"1.2.3.4", "1.2.3.9"}, Expression: "$1 * $2"},
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+			defer agent.Close()
+			agent.Set(gosnmp.SnmpPDU{Name: "1.2.3.6", Type: gosnmp.Integer, Value: 3})
+
+			registrar := NewRegistrar(registry)
+			defer registrar.Stop()
+
+			devices, err := registrar.Register(map[string]interface{}{
+				"mib":       "test-mib",
+				"version":   "v2",
+				"agent":     agent.Addr(),
+				"community": "public",
+				"timeout":   "100ms",
+			})
+			assert.NoError(t, err)
+			assert.Len(t, devices, 1)
+			assert.Equal(t, "power", devices[0].Data["key"])
+			assert.Equal(t, agent.Addr()+"-test-mib:power", SnmpDeviceIdentifier(devices[0].Data))
+
+			// The first reading uses the input values found during discovery.
+			requests := agent.Requests(gosnmp.GetRequest)
+			readings, err := handlers.Computed.Read(devices[0])
+			assert.NoError(t, err)
+			assert.Len(t, readings, 1)
+			assert.Equal(t, float64(60), readings[0].Value)
+			assert.Equal(t, requests, agent.Requests(gosnmp.GetRequest))
+		})
+	}
+}
+
+func TestRegistrar_Register_Readings(t *testing.T) {
+	for _, strategy := range []string{core.DiscoveryWalk, core.DiscoveryProbe} {
+		t.Run(strategy, func(t *testing.T) {
+			registry := mibs.NewRegistry()
+			if err := registry.Register(&mibs.MIB{
+				Name:              "test-mib",
+				DiscoveryStrategy: strategy,
+				Devices: []*mibs.SnmpDevice{
+					{OID: "1.2.3.4", Info: "outlet", Type: "power", Handler: "read-only", Output: "temperature", Readings: []*core.ReadingOid{
+						{OID: "1.2.5.1", Output: "electric-current"},
+						{OID: "1.2.5.2", Output: "watt"},
+					}},
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			agent := snmptest.NewTestAgent(t, gosnmp.Version2c, snmptest.SysObjectID("1.3.6.1.4.1.9999"), testDeviceVar)
+			defer agent.Close()
+			agent.Set(gosnmp.SnmpPDU{Name: "1.2.5.1", Type: gosnmp.Integer, Value: 3})
+
+			registrar := NewRegistrar(registry)
+			defer registrar.Stop()
+
+			devices, err := registrar.Register(map[string]interface{}{
+				"mib":       "test-mib",
+				"version":   "v2",
+				"agent":     agent.Addr(),
+				"community": "public",
+				"timeout":   "100ms",
+			})
+			assert.NoError(t, err)
+			assert.Len(t, devices, 1)
+
+			// The first reading uses the values found during discovery, and
+			// the reading OID which the agent does not support is left out.
+			requests := agent.Requests(gosnmp.GetRequest)
+			readings, err := handlers.ReadOnly.Read(devices[0])
+			assert.NoError(t, err)
+			assert.Len(t, readings, 2)
+			assert.Equal(t, 20, readings[0].Value)
+			assert.Equal(t, 3, readings[1].Value)
+			assert.Equal(t, "1.2.5.1", readings[1].Context["oid"])
+			assert.Equal(t, requests, agent.Requests(gosnmp.GetRequest))
+		})
+	}
+}
+
+func TestDiscoveryStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		mib      string
+		expected string
+	}{
+		{"default", "", "", core.DiscoveryWalk},
+		{"mib", "", core.DiscoveryProbe, core.DiscoveryProbe},
+		{"target", core.DiscoveryProbe, "", core.DiscoveryProbe},
+		{"target overrides mib", core.DiscoveryWalk, core.DiscoveryProbe, core.DiscoveryWalk},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &core.SnmpTargetConfiguration{
+				Discovery: core.SnmpDiscoveryConfiguration{Strategy: test.target},
+			}
+			mib := &mibs.MIB{Name: "test-mib", DiscoveryStrategy: test.mib}
+			assert.Equal(t, test.expected, discoveryStrategy(config, mib))
+		})
+	}
+}
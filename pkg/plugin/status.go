@@ -0,0 +1,73 @@
+package exp
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vapor-ware/synse-sdk/sdk"
+	"github.com/vapor-ware/synse-snmp-base/pkg/core"
+	"github.com/vapor-ware/synse-snmp-base/pkg/handlers"
+)
+
+// statusMIB and statusKey identify the status device of a target in place of a MIB
+// name and OID, so its device ID is derived from the agent alone.
+const (
+	statusMIB = "agent"
+	statusKey = "status"
+)
+
+// statusDevice builds the status device of a target, which reports the reachability,
+// last response time and rolling latency of the target's agent (see
+// handlers.AgentStatus).
+func statusDevice(target *core.Target) *sdk.Device {
+	return &sdk.Device{
+		Type:    "status",
+		Info:    "SNMP Agent Status",
+		Handler: handlers.AgentStatus.Name,
+		Output:  "status",
+		Tags: []*sdk.Tag{
+			core.TagOrPanic("protocol/snmp"),
+			core.TagOrPanic("snmp/agent-status"),
+		},
+		Data: map[string]interface{}{
+			"agent":      target.Config.Agent,
+			"mib":        statusMIB,
+			"key":        statusKey,
+			"target_cfg": target.Config,
+			"target":     target,
+		},
+		Context: map[string]string{
+			"agent": target.Config.Agent,
+		},
+	}
+}
+
+// monitor keeps the status of a target's agent up to date in the background, until
+// the registrar is stopped. The agent's health is maintained from the plugin's
+// traffic to the agent, so the agent is only probed (by getting its sysUpTime) once
+// there has been no traffic to it for the target's status probe interval.
+func (r *Registrar) monitor(target *core.Target) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		interval := target.Config.Status.ProbeInterval
+		health := target.Health()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+
+			if !health.Idle(interval) {
+				continue
+			}
+			log.WithField("agent", target.Config.Agent).Debug("[snmp] no recent traffic to agent; probing agent status")
+			probeUptime(target)
+		}
+	}()
+}